@@ -3,47 +3,151 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	addRef    string
+	addSubdir string
+	addName   string
+	addLink   bool
+)
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:   "add <git_repo_url>",
-	Short: "Add a new template from a Git repository",
-	Long:  `Clones a Git repository into the FORMA templates directory.`,
+	Use:   "add <git_repo_url|path|registry_id>",
+	Short: "Add a new template from a Git repository, a directory or an archive",
+	Long: `Clones a Git repository into the FORMA templates directory.
+
+Use --ref to install a specific tag, branch or commit instead of the default
+branch, and --subdir to install a template that lives in a subdirectory of the
+repository, such as one of several starters kept in a single repository. The
+URL, ref, subdirectory and installed commit are recorded in the template's
+.forma-source.yaml.
+
+A local directory, .tar.gz/.tgz or .zip archive can be added instead of a
+repository. Directories are copied, or linked with --link so changes to the
+template are picked up while developing it.
+
+A template ID found with 'forma search' installs the template listed in the
+registry, from its URL, ref and subdirectory.`,
+	Example: `  forma add https://github.com/project-starters/go-cli-template.git
+  forma add https://github.com/acme/starters.git --ref v1.4.0 --subdir templates/go-service
+  forma add https://github.com/acme/starters.git --subdir templates/go-service --name acme-go
+  forma add ./my-template --link
+  forma add starter.tar.gz
+  forma add go-service --registry ./index.yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 1 {
-			fmt.Println("Usage: forma add <git_repo_url>")
-			return
+			fmt.Println("Usage: forma add <git_repo_url|path|registry_id>")
+			os.Exit(1)
+		}
+		repoURL, displayURL := args[0], args[0]
+		ref, subdirPath, repoName := addRef, addSubdir, addName
+
+		// A bare name that is not a local path is looked up in the registry.
+		if _, err := os.Stat(repoURL); err != nil && isRegistryID(repoURL) {
+			entry, err := findRegistryTemplate(repoURL)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Found template '%s' in registry %s.\n", entry.ID, entry.registry)
+			repoURL, displayURL = entry.URL, entry.URL
+			if ref == "" {
+				ref = entry.Ref
+			}
+			if subdirPath == "" {
+				subdirPath = entry.Subdir
+			}
+			if repoName == "" {
+				repoName = entry.ID
+			}
+		}
+
+		// Anything that exists on disk is a directory or an archive, not a repository URL.
+		localInfo, err := os.Stat(repoURL)
+		local := err == nil
+		if local {
+			if repoURL, err = filepath.Abs(repoURL); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		_, isArchive := archiveName(repoURL)
+		switch {
+		case local && ref != "":
+			fmt.Println("Error: --ref can only be used with Git repositories.")
+			os.Exit(1)
+		case local && !localInfo.IsDir() && !isArchive:
+			fmt.Printf("Error: '%s' is not a directory or a .tar.gz, .tgz or .zip archive.\n", displayURL)
+			os.Exit(1)
+		case addLink && (!local || !localInfo.IsDir()):
+			fmt.Println("Error: --link can only be used with local directories.")
+			os.Exit(1)
 		}
-		repoURL := args[0]
 
 		templatesPath, err := getTemplatesPath()
 		if err != nil {
 			fmt.Printf("Error getting templates path: %v\n", err)
-			return
+			os.Exit(1)
+		}
+
+		subdir := filepath.Clean(filepath.FromSlash(subdirPath))
+		if subdirPath != "" && !filepath.IsLocal(subdir) {
+			fmt.Println("Error: --subdir must be a relative path inside the repository.")
+			os.Exit(1)
 		}
 
-		repoName := strings.TrimSuffix(filepath.Base(repoURL), ".git")
+		if repoName == "" && subdirPath != "" {
+			repoName = filepath.Base(subdir)
+		} else if name, ok := archiveName(repoURL); repoName == "" && local && ok {
+			repoName = name
+		} else if repoName == "" {
+			repoName = strings.TrimSuffix(filepath.Base(repoURL), ".git")
+		}
+		if repoName == "." || repoName == ".." || strings.ContainsAny(repoName, `/\`) {
+			fmt.Printf("Error: invalid template name '%s'.\n", repoName)
+			os.Exit(1)
+		}
 		destPath := filepath.Join(templatesPath, repoName)
 
-		if _, err := os.Stat(destPath); err == nil {
+		if _, err := os.Lstat(destPath); err == nil {
 			fmt.Printf("Template '%s' already exists in '%s'.\n", repoName, templatesPath)
-			return
+			os.Exit(1)
 		}
-		fmt.Printf("Cloning template from '%s' into '%s'...\n", repoURL, destPath)
 
-		gitCmd := exec.Command("git", "clone", repoURL, destPath)
-
-		// Run the command and capture the combined output (stdout and stderr).
-		output, err := gitCmd.CombinedOutput()
+		var origin templateOrigin
+		switch {
+		case addLink:
+			fmt.Printf("Linking template '%s' to '%s'...\n", repoName, filepath.Join(repoURL, subdir))
+			err = linkTemplate(repoURL, subdir, destPath)
+		case local && isArchive:
+			fmt.Printf("Extracting template from '%s' into '%s'...\n", displayURL, destPath)
+			origin, err = installLocalTemplate(repoURL, subdir, destPath)
+		case local:
+			fmt.Printf("Copying template from '%s' into '%s'...\n", displayURL, destPath)
+			origin, err = installLocalTemplate(repoURL, subdir, destPath)
+		default:
+			fmt.Printf("Cloning template from '%s' into '%s'...\n", repoURL, destPath)
+			origin, err = installTemplate(repoURL, ref, subdir, destPath)
+		}
 		if err != nil {
-			fmt.Printf("Error cloning repository: %v\nOutput: %s\n", err, output)
-			return
+			os.RemoveAll(destPath)
+			fmt.Printf("Error installing template: %v\n", err)
+			os.Exit(1)
+		}
+		if subdirPath != "" {
+			origin.Subdir = filepath.ToSlash(subdir)
+		}
+		// A linked template is the user's own directory, so nothing is written into it.
+		if !addLink {
+			if err := writeTemplateOrigin(destPath, origin); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
 		}
 
 		// Ensure template.yaml exists
@@ -51,13 +155,143 @@ var addCmd = &cobra.Command{
 			fmt.Printf("Warning: could not create placeholder template.yaml: %v\n", err)
 		}
 
-		fmt.Printf("Successfully added template '%s'.\n", repoName)
+		if err := trustAddedTemplate(repoName, destPath, repoURL); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
+		if origin.Revision != "" {
+			fmt.Printf("Successfully added template '%s' at %s.\n", repoName, shortRevision(origin.Revision))
+		} else {
+			fmt.Printf("Successfully added template '%s'.\n", repoName)
+		}
 		fmt.Println("You can now use this template with the 'new' command.")
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringVar(&addRef, "ref", "", "Tag, branch or commit to install instead of the default branch")
+	addCmd.Flags().StringVar(&addSubdir, "subdir", "", "Install the template from this subdirectory of the repository")
+	addCmd.Flags().StringVar(&addName, "name", "", "Name of the installed template (default: the repository or subdirectory name)")
+	addCmd.Flags().BoolVar(&addLink, "link", false, "Link a local directory instead of copying it")
+	addCmd.Flags().StringVar(&registryURL, "registry", "", "Registry index to look template IDs up in instead of the configured ones")
+}
+
+// installLocalTemplate copies a template from a local directory or extracts
+// it from an archive.
+func installLocalTemplate(sourcePath, subdir, destPath string) (templateOrigin, error) {
+	origin := templateOrigin{URL: sourcePath}
+
+	root := sourcePath
+	if _, ok := archiveName(sourcePath); ok {
+		tempPath, err := os.MkdirTemp("", "forma-extract-*")
+		if err != nil {
+			return origin, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tempPath)
+		if err := extractArchive(sourcePath, tempPath); err != nil {
+			return origin, err
+		}
+		if root, err = archiveRoot(tempPath); err != nil {
+			return origin, err
+		}
+	}
+
+	root = filepath.Join(root, subdir)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return origin, fmt.Errorf("directory '%s' does not exist", filepath.ToSlash(subdir))
+	}
+	if err := copyDir(root, destPath); err != nil {
+		return origin, fmt.Errorf("failed to copy template: %w", err)
+	}
+	return origin, nil
+}
+
+// linkTemplate installs a local directory as a symlink, so edits to it are
+// used right away.
+func linkTemplate(sourcePath, subdir, destPath string) error {
+	target := filepath.Join(sourcePath, subdir)
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory '%s' does not exist", filepath.ToSlash(subdir))
+	}
+	return os.Symlink(target, destPath)
+}
+
+// installTemplate clones a repository and checks out ref, if given. Without a
+// subdirectory the clone itself becomes the template; otherwise the repository
+// is cloned to a temporary directory and only the subdirectory is copied.
+func installTemplate(repoURL, ref, subdir, destPath string) (templateOrigin, error) {
+	origin := templateOrigin{URL: repoURL, Ref: ref}
+
+	clonePath := destPath
+	if subdir != "." {
+		tempPath, err := os.MkdirTemp("", "forma-clone-*")
+		if err != nil {
+			return origin, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tempPath)
+		clonePath = tempPath
+	}
+
+	repo, err := gitClone(repoURL, clonePath, true, os.Stdout)
+	if err != nil {
+		return origin, err
+	}
+	if ref != "" {
+		hash, err := gitResolve(repo, ref)
+		if err != nil {
+			return origin, err
+		}
+		// Branches are checked out as a local branch, like `git checkout <branch>` does.
+		branch := ""
+		if gitRemoteBranch(repo, ref) {
+			branch = ref
+		}
+		if err := gitCheckout(repo, hash, branch, false); err != nil {
+			return origin, err
+		}
+	}
+	if origin.Revision, err = gitRevision(clonePath); err != nil {
+		return origin, err
+	}
+
+	if subdir != "." {
+		sourcePath := filepath.Join(clonePath, subdir)
+		if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+			return origin, fmt.Errorf("directory '%s' does not exist in the repository", filepath.ToSlash(subdir))
+		}
+		if err := copyDir(sourcePath, destPath); err != nil {
+			return origin, fmt.Errorf("failed to copy template: %w", err)
+		}
+	}
+	return origin, nil
+}
+
+// trustAddedTemplate approves the hooks of a template added from a trusted
+// source, and otherwise tells the user that its hooks are disabled.
+func trustAddedTemplate(templateName, templatePath, source string) error {
+	config, err := loadTemplateConfig(templatePath)
+	if err != nil {
+		return err
+	}
+	if !hasHooks(config.Hooks) {
+		return nil
+	}
+
+	store, err := loadTrustStore()
+	if err != nil {
+		return err
+	}
+	if !store.trustedSource(source) {
+		delete(store.Templates, templateName)
+		fmt.Printf("Hooks of this template are disabled until you review them with 'forma trust %s'.\n", templateName)
+		return store.save()
+	}
+	if err := store.approve(templateName, config.Hooks); err != nil {
+		return err
+	}
+	fmt.Println("Hooks of this template are trusted because it comes from a trusted source.")
+	return store.save()
 }
 
 func ensureTemplateYAML(destPath, repoName string) error {
@@ -68,10 +302,10 @@ func ensureTemplateYAML(destPath, repoName string) error {
 description: "Placeholder template.yaml. Please customize."
 hooks:
   post_create:
-    - "git init"
-    - "git add ."
-    - "git commit -m 'feat: initial commit from forma template'"
     - "echo '%s project initialized. Run with: go run .'"
+git:
+  init: true
+  commit: "feat: initial commit from forma template"
 `, repoName, repoName)
 		return os.WriteFile(templatePath, []byte(defaultYAML), 0644)
 	}
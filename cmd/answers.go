@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadAnswers reads pre-filled answers from a YAML file, if given, and applies
+// key=value overrides from --set on top of them. Keys are variable names or
+// the built-in ProjectName and Author. The file may also be the record of a
+// previously generated project.
+func loadAnswers(path string, sets []string) (map[string]interface{}, error) {
+	answers := make(map[string]interface{})
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answers file: %w", err)
+		}
+		if err := yaml.Unmarshal(content, &answers); err != nil {
+			return nil, fmt.Errorf("failed to parse answers file: %w", err)
+		}
+		if answers == nil {
+			answers = make(map[string]interface{})
+		}
+		// Accept a project's .forma-answers.yaml, which nests the values under "answers".
+		if _, ok := answers["template"]; ok {
+			if recorded, ok := answers["answers"].(map[string]interface{}); ok {
+				answers = recorded
+			}
+		}
+	}
+
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set value %q, expected key=value", set)
+		}
+		answers[key] = value
+	}
+	return answers, nil
+}
+
+// stringAnswer returns an answer as a string, or "" if it is not set.
+func stringAnswer(answers map[string]interface{}, key string) string {
+	value, ok := answers[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// missingVariables returns the names of variables that have neither an answer
+// nor a declared default.
+func missingVariables(vars []Variable, answers map[string]interface{}) []string {
+	var missing []string
+	for _, v := range vars {
+		if _, ok := answers[v.Name]; !ok && v.Default == nil {
+			missing = append(missing, v.Name)
+		}
+	}
+	return missing
+}
+
+// confirm asks a yes/no question on the terminal. With --yes it answers yes
+// without asking, and with --no-input it fails instead of waiting for input.
+func confirm(question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if noInput {
+		return false, fmt.Errorf("confirmation required for %q; rerun with --yes", question)
+	}
+
+	fmt.Printf("%s (y/n): ", question)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "y", nil
+}
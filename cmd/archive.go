@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveSuffixes are the file extensions of template archives `forma add` can install.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".zip"}
+
+// archiveName returns the name of an archive without its extension, and
+// false if the path is not a supported archive.
+func archiveName(path string) (string, bool) {
+	base := filepath.Base(path)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(strings.ToLower(base), suffix) {
+			return base[:len(base)-len(suffix)], true
+		}
+	}
+	return "", false
+}
+
+// extractArchive unpacks a .tar.gz or .zip archive into dest.
+func extractArchive(archivePath, dest string) error {
+	var err error
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		err = extractZip(archivePath, dest)
+	} else {
+		err = extractTarGz(archivePath, dest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(archivePath), err)
+	}
+	return nil
+}
+
+// archiveWriter writes archive entries below a directory. Symlinks are only
+// created once every regular file has been written, and no entry may be
+// nested under a symlink entry, so nothing is written through a link that
+// points outside the directory.
+type archiveWriter struct {
+	dest     string
+	symlinks [][2]string
+	// linked holds the targets of the symlink entries.
+	linked map[string]bool
+}
+
+// target validates an entry name and returns where it is extracted to.
+func (w *archiveWriter) target(name string) (string, error) {
+	name = strings.TrimPrefix(filepath.FromSlash(name), "."+string(filepath.Separator))
+	name = filepath.Clean(name)
+	if name == "." {
+		return w.dest, nil
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("archive entry %q is outside the archive", name)
+	}
+	target := filepath.Join(w.dest, name)
+	if err := w.checkParents(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// checkParents returns an error if a parent directory of target is a
+// symlink entry.
+func (w *archiveWriter) checkParents(target string) error {
+	for dir := filepath.Dir(target); dir != w.dest && len(dir) > len(w.dest); dir = filepath.Dir(dir) {
+		if w.linked[dir] {
+			rel, _ := filepath.Rel(w.dest, target)
+			return fmt.Errorf("archive entry %q is inside a symlink", filepath.ToSlash(rel))
+		}
+	}
+	return nil
+}
+
+func (w *archiveWriter) dir(name string) error {
+	target, err := w.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+func (w *archiveWriter) file(name string, mode fs.FileMode, content io.Reader) error {
+	target, err := w.target(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (w *archiveWriter) symlink(name, link string) error {
+	target, err := w.target(name)
+	if err != nil {
+		return err
+	}
+	if target == w.dest {
+		return fmt.Errorf("archive root is a symlink")
+	}
+	if w.linked == nil {
+		w.linked = make(map[string]bool)
+	}
+	w.linked[target] = true
+	w.symlinks = append(w.symlinks, [2]string{target, link})
+	return nil
+}
+
+// finish creates the symlinks collected while extracting. A symlink listed
+// before the one it is nested under is only caught here.
+func (w *archiveWriter) finish() error {
+	for _, symlink := range w.symlinks {
+		if err := w.checkParents(symlink[0]); err != nil {
+			return err
+		}
+	}
+	for _, symlink := range w.symlinks {
+		if err := os.MkdirAll(filepath.Dir(symlink[0]), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(symlink[1], symlink[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTarGz unpacks a gzip-compressed tar archive.
+func extractTarGz(archivePath, dest string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	w := &archiveWriter{dest: dest}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = w.dir(header.Name)
+		case tar.TypeReg:
+			err = w.file(header.Name, header.FileInfo().Mode(), reader)
+		case tar.TypeSymlink:
+			err = w.symlink(header.Name, header.Linkname)
+		case tar.TypeXGlobalHeader:
+			// Metadata such as the commit of `git archive`, nothing to extract.
+		default:
+			err = fmt.Errorf("archive entry %q has an unsupported type", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.finish()
+}
+
+// extractZip unpacks a zip archive.
+func extractZip(archivePath, dest string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	w := &archiveWriter{dest: dest}
+	for _, entry := range reader.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			err = w.dir(entry.Name)
+		case mode&fs.ModeSymlink != 0:
+			var link []byte
+			if link, err = readZipEntry(entry); err == nil {
+				err = w.symlink(entry.Name, string(link))
+			}
+		default:
+			var content io.ReadCloser
+			if content, err = entry.Open(); err == nil {
+				err = w.file(entry.Name, mode, content)
+				content.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.finish()
+}
+
+// readZipEntry returns the content of a zip entry.
+func readZipEntry(entry *zip.File) ([]byte, error) {
+	content, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return io.ReadAll(content)
+}
+
+// archiveRoot returns the directory holding the template in an extracted
+// archive. Archives often wrap everything in a single top-level directory,
+// which is stepped into unless the archive root holds a template.yaml.
+func archiveRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "template.yaml")); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry is an entry of an archive built by a test. Names ending in a
+// slash are directories; entries with a link are symlinks.
+type archiveEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+func writeTarGz(t *testing.T, path string, entries []archiveEntry) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.content))}
+		switch {
+		case entry.link != "":
+			header = &tar.Header{Name: entry.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: entry.link}
+		case strings.HasSuffix(entry.name, "/"):
+			header = &tar.Header{Name: entry.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, path string, entries []archiveEntry) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		content := entry.content
+		switch {
+		case entry.link != "":
+			header.SetMode(fs.ModeSymlink | 0777)
+			content = entry.link
+		case strings.HasSuffix(entry.name, "/"):
+			header.SetMode(fs.ModeDir | 0755)
+		default:
+			header.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		// wantErr is part of the expected error, or "" for success.
+		wantErr string
+		// files maps paths below the destination to their expected content.
+		files map[string]string
+	}{
+		{
+			name: "files and directories",
+			entries: []archiveEntry{
+				{name: "tpl/"},
+				{name: "tpl/template.yaml", content: "name: tpl\n"},
+				{name: "./tpl/src/main.go", content: "package main\n"},
+			},
+			files: map[string]string{
+				"tpl/template.yaml": "name: tpl\n",
+				"tpl/src/main.go":   "package main\n",
+			},
+		},
+		{
+			name: "symlink inside the archive",
+			entries: []archiveEntry{
+				{name: "tpl/README.md", content: "readme"},
+				{name: "tpl/README", link: "README.md"},
+			},
+			files: map[string]string{"tpl/README": "readme"},
+		},
+		{
+			name:    "parent directory",
+			entries: []archiveEntry{{name: "../evil", content: "x"}},
+			wantErr: "outside the archive",
+		},
+		{
+			name:    "parent directory after a subdirectory",
+			entries: []archiveEntry{{name: "tpl/../../evil", content: "x"}},
+			wantErr: "outside the archive",
+		},
+		{
+			name:    "absolute path",
+			entries: []archiveEntry{{name: "/tmp/evil", content: "x"}},
+			wantErr: "outside the archive",
+		},
+		{
+			name: "file under a symlink",
+			entries: []archiveEntry{
+				{name: "tpl/out", link: "/tmp"},
+				{name: "tpl/out/evil", content: "x"},
+			},
+			wantErr: "inside a symlink",
+		},
+		{
+			name: "directory under a symlink",
+			entries: []archiveEntry{
+				{name: "tpl/out", link: ".."},
+				{name: "tpl/out/dir/"},
+			},
+			wantErr: "inside a symlink",
+		},
+		{
+			name: "symlink under a symlink",
+			entries: []archiveEntry{
+				{name: "tpl/out", link: "/tmp"},
+				{name: "tpl/out/evil", link: "/etc/passwd"},
+			},
+			wantErr: "inside a symlink",
+		},
+		{
+			name: "symlink under a later symlink",
+			entries: []archiveEntry{
+				{name: "tpl/out/evil", link: "/etc/passwd"},
+				{name: "tpl/out", link: "/tmp"},
+			},
+			wantErr: "inside a symlink",
+		},
+	}
+
+	formats := []struct {
+		suffix string
+		write  func(*testing.T, string, []archiveEntry)
+	}{
+		{".tar.gz", writeTarGz},
+		{".zip", writeZip},
+	}
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.suffix+"/"+tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+				archivePath := filepath.Join(dir, "tpl"+format.suffix)
+				format.write(t, archivePath, tt.entries)
+				dest := filepath.Join(dir, "dest")
+
+				err := extractArchive(archivePath, dest)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("extractArchive() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("extractArchive() error = %v", err)
+				}
+				for name, want := range tt.files {
+					got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+					if err != nil {
+						t.Errorf("reading %s: %v", name, err)
+					} else if string(got) != want {
+						t.Errorf("%s = %q, want %q", name, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/tmp/go-api.tar.gz", "go-api", true},
+		{"go-api-1.2.TGZ", "go-api-1.2", true},
+		{"dir/go-api.zip", "go-api", true},
+		{"go-api.tar", "", false},
+		{"go-api", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := archiveName(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("archiveName(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffSummary  bool
+	diffExitCode bool
+	diffRecorded bool
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [project_dir]",
+	Short: "Shows how a project has drifted from its template.",
+	Long: `Re-renders the template recorded in the project's .forma-answers.yaml with the
+recorded answers and compares the result with the project. Only files the
+template generates are compared; files that exist only in the project are ignored.
+
+By default the installed template is used. With --recorded, the template revision
+the project was generated from is used instead, which shows only local changes.`,
+	Example: `  forma diff
+  forma diff services/billing --summary
+  forma diff --exit-code`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath := "."
+		if len(args) == 1 {
+			projectPath = args[0]
+		}
+
+		record, err := readProjectRecord(projectPath)
+		if err != nil {
+			fmt.Printf("Error reading project record: %v\n", err)
+			os.Exit(2)
+		}
+
+		templatesPath, err := getTemplatesPath()
+		if err != nil {
+			fmt.Printf("Error getting templates path: %v\n", err)
+			os.Exit(2)
+		}
+		templatePath := filepath.Join(templatesPath, record.Template)
+
+		revision := ""
+		if diffRecorded {
+			if record.Revision == "" {
+				fmt.Printf("Error: no template revision is recorded for '%s'.\n", projectPath)
+				os.Exit(2)
+			}
+			revision = record.Revision
+		}
+
+		renderPath, _, err := renderRecorded(templatePath, revision, record)
+		if err != nil {
+			fmt.Printf("Error rendering template: %v\n", err)
+			os.Exit(2)
+		}
+		changed, err := diffProject(renderPath, projectPath, diffSummary)
+		os.RemoveAll(renderPath)
+		if err != nil {
+			fmt.Printf("Error comparing project: %v\n", err)
+			os.Exit(2)
+		}
+
+		if changed == 0 {
+			fmt.Printf("No differences from template '%s'.\n", record.Template)
+			return
+		}
+		if diffSummary {
+			fmt.Printf("\n%d file(s) differ from template '%s'.\n", changed, record.Template)
+		}
+		if diffExitCode {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&diffSummary, "summary", false, "List modified and deleted files instead of showing a diff")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 if there are differences")
+	diffCmd.Flags().BoolVar(&diffRecorded, "recorded", false, "Compare against the recorded template revision instead of the installed one")
+}
+
+// diffProject compares every file of a rendered template with the project and
+// prints either a unified diff or a one-line summary per file. It returns the
+// number of files that differ.
+func diffProject(renderPath, projectPath string, summary bool) (int, error) {
+	files, err := listFiles(renderPath)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, file := range files {
+		expected, err := readEntry(filepath.Join(renderPath, file))
+		if err != nil {
+			return changed, err
+		}
+		actual, err := readEntry(filepath.Join(projectPath, file))
+		if err != nil {
+			return changed, err
+		}
+		if expected.equal(actual) {
+			continue
+		}
+		changed++
+
+		status := "M"
+		if !actual.exists {
+			status = "D"
+		}
+		if summary {
+			fmt.Printf("%s %s\n", status, file)
+			continue
+		}
+
+		fmt.Printf("diff template/%s project/%s\n", file, file)
+		if expected.symlink || actual.symlink || isBinary(expected.content) || isBinary(actual.content) {
+			fmt.Printf("Binary files or symlinks differ\n")
+			continue
+		}
+		toName := "project/" + file
+		if !actual.exists {
+			toName = "/dev/null"
+		}
+		fmt.Print(unifiedDiff("template/"+file, toName, splitLines(expected.content), splitLines(actual.content)))
+	}
+	return changed, nil
+}
+
+// splitLines splits content into lines, keeping a missing final newline visible.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	text := string(content)
+	noNewline := !strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if noNewline {
+		lines[len(lines)-1] += "\n\\ No newline at end of file"
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' deleted or '+' inserted.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines computes the shortest edit script turning a into b using the
+// linear-space variant of Myers' algorithm, so large rewritten files only
+// need memory proportional to their length.
+func diffLines(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	return diffRange(ops, a, b)
+}
+
+// diffRange appends the edit script turning a into b to ops.
+func diffRange(ops []diffOp, a, b []string) []diffOp {
+	// Common lines at the start and end are kept as they are.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	x, y, ok := 0, 0, false
+	if len(a) > 0 && len(b) > 0 {
+		x, y, ok = middleSnake(a, b)
+	}
+	if ok {
+		ops = diffRange(ops, a[:x], b[:y])
+		ops = diffRange(ops, a[x:], b[y:])
+	} else {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range common {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// middleSnake searches the shortest edit script from both ends at once and
+// returns a point on it where a and b can be split into two smaller
+// problems. It reports false if a and b have no line in common.
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	// When the length difference is odd, the paths can only meet while
+	// extending the forward one, otherwise while extending the backward one.
+	delta := n - m
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edge of the edit graph are not extended again.
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return splitPoint(n, m, x, y)
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 {
+					fx := forward[i]
+					fy := fx - (i - offset)
+					if fx >= n-x {
+						return splitPoint(n, m, fx, fy)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitPoint returns a split of the edit graph, refusing one that would not
+// make the problem smaller.
+func splitPoint(n, m, x, y int) (int, int, bool) {
+	if (x == 0 && y == 0) || (x == n && y == m) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// unifiedDiff formats the differences between a and b as a unified diff.
+func unifiedDiff(fromName, toName string, a, b []string) string {
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+		last := end + diffContext
+		if last > len(ops) {
+			last = len(ops)
+		}
+
+		// Line numbers of the hunk start in a and b.
+		aLine, bLine := 1, 1
+		for _, op := range ops[:first] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[first:last] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aLine--
+		}
+		if bCount == 0 {
+			bLine--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, op := range ops[first:last] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		start = last
+	}
+	return out.String()
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// applyOps returns the old and new sides of an edit script.
+func applyOps(ops []diffOp) (a, b []string) {
+	for _, op := range ops {
+		if op.kind != '+' {
+			a = append(a, op.line)
+		}
+		if op.kind != '-' {
+			b = append(b, op.line)
+		}
+	}
+	return a, b
+}
+
+// editCount returns the number of inserted and deleted lines of an edit script.
+func editCount(ops []diffOp) int {
+	count := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			count++
+		}
+	}
+	return count
+}
+
+// lcsEdits returns the length of the shortest edit script, using the
+// quadratic longest common subsequence.
+func lcsEdits(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a b c", "a b c", " a  b  c"},
+		{"both empty", "", "", ""},
+		{"insert into empty", "", "a b", "+a +b"},
+		{"delete everything", "a b", "", "-a -b"},
+		{"insert in middle", "a c", "a b c", " a +b  c"},
+		{"delete in middle", "a b c", "a c", " a -b  c"},
+		{"replace line", "a b c", "a x c", " a -b +x  c"},
+		{"insert at start", "b c", "a b c", "+a  b  c"},
+		{"append", "a b", "a b c", " a  b +c"},
+		{"no common line", "a b", "x y", "-a -b +x +y"},
+		{"single lines", "a", "b", "-a +b"},
+		{"moved line", "a b c d", "b c d a", "-a  b  c  d +a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, op := range diffLines(strings.Fields(tt.a), strings.Fields(tt.b)) {
+				got = append(got, string(op.kind)+op.line)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := random(), random()
+		ops := diffLines(a, b)
+		gotA, gotB := applyOps(ops)
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("diffLines(%q, %q) does not reproduce its inputs: %q", a, b, ops)
+		}
+		if got, want := editCount(ops), lcsEdits(a, b); got != want {
+			t.Fatalf("diffLines(%q, %q) has %d edits, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestDiffLinesRewrite(t *testing.T) {
+	// A file rewritten in full, such as a regenerated lockfile.
+	a := make([]string, 4000)
+	b := make([]string, 4000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d", i)
+		b[i] = fmt.Sprintf("new %d", i)
+	}
+	allocs := testing.AllocsPerRun(1, func() {
+		if ops := diffLines(a, b); editCount(ops) != 8000 {
+			t.Fatalf("got %d edits, want 8000", editCount(ops))
+		}
+	})
+	if allocs > 100 {
+		t.Errorf("diffLines made %v allocations", allocs)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := func(from, to int) []string {
+		var lines []string
+		for i := from; i <= to; i++ {
+			lines = append(lines, fmt.Sprint(i))
+		}
+		return lines
+	}
+	without := func(lines []string, i int) []string {
+		return append(append([]string{}, lines[:i]...), lines[i+1:]...)
+	}
+	with := func(lines []string, i int, line string) []string {
+		return append(append(append([]string{}, lines[:i]...), line), lines[i:]...)
+	}
+	ten := numbered(1, 10)
+
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"no change", ten, ten, ""},
+		{"new file", nil, []string{"a", "b"}, "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"deleted file", []string{"a", "b"}, nil, "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{
+			"insert only", ten, with(ten, 5, "x"),
+			"@@ -3,6 +3,7 @@\n 3\n 4\n 5\n+x\n 6\n 7\n 8\n",
+		},
+		{
+			"delete only", ten, without(ten, 4),
+			"@@ -2,7 +2,6 @@\n 2\n 3\n 4\n-5\n 6\n 7\n 8\n",
+		},
+		{
+			"insert at start", ten, with(ten, 0, "x"),
+			"@@ -1,3 +1,4 @@\n+x\n 1\n 2\n 3\n",
+		},
+		{
+			"delete at end", ten, ten[:9],
+			"@@ -7,4 +7,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			"separate hunks", numbered(1, 20), with(without(numbered(1, 20), 1), 17, "x"),
+			"@@ -1,5 +1,4 @@\n 1\n-2\n 3\n 4\n 5\n@@ -16,5 +15,6 @@\n 16\n 17\n 18\n+x\n 19\n 20\n",
+		},
+		{
+			"close changes share a hunk", ten, with(without(ten, 2), 5, "x"),
+			"@@ -1,9 +1,9 @@\n 1\n 2\n-3\n 4\n 5\n 6\n+x\n 7\n 8\n 9\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := "--- old\n+++ new\n" + tt.want
+			if got := unifiedDiff("old", "new", tt.a, tt.b); got != want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
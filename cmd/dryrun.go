@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// printDryRun renders a template in memory and prints the files it would
+// create, the files it would overwrite, the hook commands it would run and
+// whether a git repository would be created.
+func printDryRun(templateName, templatePath, projectPath string, config TemplateConfig, data TemplateData) error {
+	files, err := renderTemplate(templatePath, config, data)
+	if err != nil {
+		return err
+	}
+	record, err := encodeProjectRecord(newProjectRecord(templateName, templatePath, data))
+	if err != nil {
+		return err
+	}
+	files = append(files, plannedFile{path: recordFileName, content: record, mode: 0644})
+	sortPlannedFiles(files)
+
+	fmt.Printf("Dry run: nothing will be written and no hooks will be run.\n\n")
+
+	projectExists := false
+	if _, err := os.Stat(projectPath); err == nil {
+		projectExists = true
+		if intoDir != "" {
+			fmt.Printf("Files would be written into the existing directory '%s'.\n\n", projectPath)
+		} else {
+			fmt.Printf("⚠️  '%s' already exists and would be replaced after confirmation.\n\n", projectPath)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	fmt.Printf("%s/\n", filepath.Base(projectPath))
+	var overwritten []string
+	for _, file := range files {
+		depth := strings.Count(filepath.ToSlash(file.path), "/")
+		line := strings.Repeat("  ", depth+1) + filepath.Base(file.path)
+
+		switch {
+		case file.isDir:
+			line += "/"
+		case file.symlink:
+			line += " -> " + string(file.content)
+		default:
+			line += fmt.Sprintf("  (%s, %d bytes)", file.mode, len(file.content))
+		}
+
+		if projectExists && !file.isDir {
+			existing, err := readEntry(filepath.Join(projectPath, file.path))
+			if err != nil {
+				return err
+			}
+			planned := fileEntry{exists: true, symlink: file.symlink, content: file.content}
+			if existing.equal(planned) {
+				line += "  [unchanged]"
+			} else if existing.exists && intoDir != "" {
+				line += "  [conflict]"
+				overwritten = append(overwritten, file.path)
+			} else if existing.exists {
+				line += "  [overwrite]"
+				overwritten = append(overwritten, file.path)
+			}
+		}
+		fmt.Println(line)
+	}
+
+	if len(overwritten) > 0 {
+		if intoDir != "" {
+			fmt.Printf("\n%d existing file(s) conflict with the template:\n", len(overwritten))
+		} else {
+			fmt.Printf("\n%d existing file(s) would be overwritten:\n", len(overwritten))
+		}
+		for _, path := range overwritten {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	stages := []struct {
+		name  string
+		hooks []Hook
+	}{
+		{stagePrePrompt, config.Hooks.PrePrompt},
+		{stagePreCreate, config.Hooks.PreCreate},
+		{stagePostRender, nil},
+		{stagePostCreate, config.Hooks.PostCreate},
+	}
+	for _, stage := range stages {
+		var rendered []renderedHook
+		if stage.name == stagePostRender {
+			rendered, err = renderPostRenderHooks(config.Hooks.PostRender, files, data)
+		} else {
+			rendered, err = renderHooks(stage.name, stage.hooks, data)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rendered) == 0 {
+			continue
+		}
+
+		fmt.Printf("\nHooks that would be executed at %s:\n", stage.name)
+		printHooks(rendered)
+	}
+
+	if config.Git.Init && intoDir == "" {
+		if config.Git.Commit != "" {
+			fmt.Printf("\nA git repository would be initialised with the commit %q.\n", config.Git.Commit)
+		} else {
+			fmt.Println("\nA git repository would be initialised.")
+		}
+	}
+	return nil
+}
+
+// sortPlannedFiles orders files so that every directory is directly followed by its contents.
+func sortPlannedFiles(files []plannedFile) {
+	sort.Slice(files, func(i, j int) bool {
+		a := strings.Split(filepath.ToSlash(files[i].path), "/")
+		b := strings.Split(filepath.ToSlash(files[j].path), "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// templateFuncs is the function library available in file contents, file names
+// and hook commands. Functions taking a string as their last argument work in
+// pipelines, e.g. {{ .ProjectName | pascal }} or {{ .Name | replace "-" "_" }}.
+var templateFuncs = template.FuncMap{
+	"pascal":    toPascal,
+	"camel":     toCamel,
+	"snake":     toSnake,
+	"kebab":     toKebab,
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"title":     toTitle,
+	"plural":    toPlural,
+	"trim":      strings.TrimSpace,
+	"replace":   replaceAll,
+	"join":      join,
+	"has":       has,
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"default":   defaultValue,
+	"now":       now,
+	"uuid":      newUUID,
+	"env":       os.Getenv,
+	"shquote":   shquote,
+}
+
+// splitWords breaks an identifier into words on separators and case changes,
+// so "my-API_server2" and "myAPIServer2" both become [my API server2].
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Split before "Server" in "myServer" and in "APIServer".
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// capitalize upper-cases the first letter of a word and lower-cases the rest.
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// toPascal converts "my-project" to "MyProject".
+func toPascal(s string) string {
+	var b strings.Builder
+	for _, word := range splitWords(s) {
+		b.WriteString(capitalize(word))
+	}
+	return b.String()
+}
+
+// toCamel converts "my-project" to "myProject".
+func toCamel(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// toSnake converts "MyProject" to "my_project".
+func toSnake(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// toKebab converts "MyProject" to "my-project".
+func toKebab(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// toTitle converts "my-project" to "My Project".
+func toTitle(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// toPlural applies simple English pluralization rules: "service" becomes
+// "services", "box" becomes "boxes" and "policy" becomes "policies".
+func toPlural(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+// replaceAll replaces every occurrence of old with new in s.
+func replaceAll(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// join joins a list, such as a multi-choice variable, with a separator.
+func join(sep string, list interface{}) string {
+	var items []string
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	for i := 0; i < v.Len(); i++ {
+		items = append(items, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(items, sep)
+}
+
+// has reports whether a list, such as a multi-choice variable, contains item.
+func has(list interface{}, item interface{}) bool {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if fmt.Sprint(v.Index(i).Interface()) == fmt.Sprint(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue returns def when value is empty: nil, false, zero, or an empty string or list.
+func defaultValue(def interface{}, value interface{}) interface{} {
+	if value == nil {
+		return def
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	default:
+		if v.IsZero() {
+			return def
+		}
+	}
+	return value
+}
+
+// shquote quotes a value for use as a single word in a shell command, e.g.
+// gofmt -w {{ .File | shquote }}.
+func shquote(value interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(value), "'", `'\''`) + "'"
+}
+
+// now formats the current time with a Go layout, e.g. {{ now "2006" }}.
+func now(layout string) string {
+	return time.Now().Format(layout)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate uuid: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
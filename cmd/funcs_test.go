@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShquote(t *testing.T) {
+	for _, value := range []string{
+		"main.go",
+		"a b.go",
+		"$(touch pwned).go",
+		"it's.go",
+		"`id`;rm -rf x",
+		"",
+	} {
+		quoted := shquote(value)
+		out, err := exec.Command("sh", "-c", "printf %s "+quoted).Output()
+		if err != nil {
+			t.Fatalf("sh -c %q: %v", "printf %s "+quoted, err)
+		}
+		if string(out) != value {
+			t.Errorf("shquote(%q) = %s, which the shell reads as %q", value, quoted, out)
+		}
+	}
+}
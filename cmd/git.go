@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+// Templates are cloned, fetched and checked out in-process, so forma works
+// on machines without a git executable.
+
+func init() {
+	// go-git serves local repositories by running git-upload-pack; serve them
+	// in-process instead.
+	client.InstallProtocol("file", server.NewClient(localRepositoryLoader{}))
+}
+
+// localRepositoryLoader opens local repositories for the in-process file transport.
+type localRepositoryLoader struct{}
+
+func (localRepositoryLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	repo, err := git.PlainOpen(ep.Path)
+	if err != nil {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	return repo.Storer, nil
+}
+
+// gitError is a failed git operation on a repository.
+type gitError struct {
+	Op   string
+	Repo string
+	Err  error
+}
+
+func (e *gitError) Error() string {
+	msg := e.Err.Error()
+	switch {
+	case errors.Is(e.Err, transport.ErrAuthenticationRequired), errors.Is(e.Err, transport.ErrAuthorizationFailed):
+		msg += " (for private repositories, use an SSH URL with a running ssh-agent)"
+	case errors.Is(e.Err, git.ErrUnstagedChanges):
+		msg += " (the template has local changes)"
+	}
+	return fmt.Sprintf("failed to %s %s: %s", e.Op, e.Repo, msg)
+}
+
+func (e *gitError) Unwrap() error {
+	return e.Err
+}
+
+// gitClone clones a repository into dir with all its tags. The default branch
+// is checked out unless checkout is false. Progress reported by the server is
+// written to progress, if not nil.
+func gitClone(url, dir string, checkout bool, progress io.Writer) (*git.Repository, error) {
+	options := &git.CloneOptions{
+		URL:        url,
+		NoCheckout: !checkout,
+		Tags:       git.AllTags,
+	}
+	if progress != nil {
+		options.Progress = progress
+	}
+	repo, err := git.PlainClone(dir, false, options)
+	if err != nil {
+		return nil, &gitError{Op: "clone", Repo: url, Err: err}
+	}
+	return repo, nil
+}
+
+// gitOpen opens the repository of a template checkout.
+func gitOpen(repoPath string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, &gitError{Op: "open", Repo: repoPath, Err: err}
+	}
+	return repo, nil
+}
+
+// gitFetch fetches new commits and tags from the origin remote.
+func gitFetch(repo *git.Repository) error {
+	err := repo.Fetch(&git.FetchOptions{RemoteName: "origin", Tags: git.AllTags, Force: true})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return &gitError{Op: "fetch", Repo: gitRemoteURL(repo), Err: err}
+	}
+	return nil
+}
+
+// gitRemoteURL returns the URL of the origin remote, or "" if there is none.
+func gitRemoteURL(repo *git.Repository) string {
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+// gitResolve returns the commit a tag, branch, remote branch or commit
+// refers to.
+func gitResolve(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	if hash, err := repo.ResolveRevision(plumbing.Revision("origin/" + rev)); err == nil {
+		return *hash, nil
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unknown ref %s", rev)
+	}
+	return *hash, nil
+}
+
+// gitRemoteBranch reports whether name is a branch of the origin remote.
+func gitRemoteBranch(repo *git.Repository, name string) bool {
+	_, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", name), false)
+	return err == nil
+}
+
+// gitDefaultBranch returns the name of the origin remote's default branch.
+func gitDefaultBranch(repo *git.Repository) (string, error) {
+	if ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().Short(), "origin/"), nil
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the default branch: %w", err)
+	}
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return "", &gitError{Op: "list references of", Repo: gitRemoteURL(repo), Err: err}
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", fmt.Errorf("failed to find the default branch")
+}
+
+// gitCheckout checks out a commit. If branch is given, that branch is created
+// or reset to the commit and checked out, like `git checkout -B`; otherwise
+// the commit is checked out on a detached HEAD. Unless force is set, local
+// changes to tracked files make the checkout fail.
+func gitCheckout(repo *git.Repository, hash plumbing.Hash, branch string, force bool) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return &gitError{Op: "check out", Repo: shortRevision(hash.String()), Err: err}
+	}
+	options := &git.CheckoutOptions{Hash: hash, Force: force}
+	if branch != "" {
+		name := plumbing.NewBranchReferenceName(branch)
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			return &gitError{Op: "check out", Repo: branch, Err: err}
+		}
+		options = &git.CheckoutOptions{Branch: name, Force: force}
+	}
+	if err := worktree.Checkout(options); err != nil {
+		return &gitError{Op: "check out", Repo: shortRevision(hash.String()), Err: err}
+	}
+	return nil
+}
+
+// gitIsAncestor reports whether commit a is an ancestor of commit b.
+func gitIsAncestor(repo *git.Repository, a, b string) bool {
+	from, err := repo.CommitObject(plumbing.NewHash(a))
+	if err != nil {
+		return false
+	}
+	to, err := repo.CommitObject(plumbing.NewHash(b))
+	if err != nil {
+		return false
+	}
+	ancestor, err := from.IsAncestor(to)
+	return err == nil && ancestor
+}
+
+// gitLog returns "<short hash> <subject>" for every commit reachable from to
+// but not from from, newest first. With a subdir, only commits changing files
+// below it are listed.
+func gitLog(repo *git.Repository, from, to, subdir string) ([]string, error) {
+	seen := make(map[plumbing.Hash]bool)
+	if from != "" {
+		start, err := repo.CommitObject(plumbing.NewHash(from))
+		if err != nil {
+			return nil, err
+		}
+		err = object.NewCommitPreorderIter(start, nil, nil).ForEach(func(c *object.Commit) error {
+			seen[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	options := &git.LogOptions{From: plumbing.NewHash(to), Order: git.LogOrderCommitterTime}
+	if subdir != "" {
+		prefix := strings.TrimSuffix(filepath.ToSlash(subdir), "/") + "/"
+		options.PathFilter = func(path string) bool { return strings.HasPrefix(path, prefix) }
+	}
+	commits, err := repo.Log(options)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	err = commits.ForEach(func(c *object.Commit) error {
+		if !seen[c.Hash] {
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			lines = append(lines, shortRevision(c.Hash.String())+" "+subject)
+		}
+		return nil
+	})
+	return lines, err
+}
+
+// gitInitialCommit initialises a repository in a new project, unless it is
+// one already, and commits every file not ignored by .gitignore. The author is
+// taken from the git configuration, falling back to the given name.
+func gitInitialCommit(projectPath, message, author string) error {
+	repo, err := git.PlainInit(projectPath, false)
+	if errors.Is(err, git.ErrRepositoryAlreadyExists) {
+		repo, err = git.PlainOpen(projectPath)
+	}
+	if err != nil {
+		return &gitError{Op: "initialise a repository in", Repo: projectPath, Err: err}
+	}
+	if message == "" {
+		return nil
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return &gitError{Op: "commit in", Repo: projectPath, Err: err}
+	}
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return &gitError{Op: "add files in", Repo: projectPath, Err: err}
+	}
+	_, err = worktree.Commit(message, &git.CommitOptions{})
+	if errors.Is(err, git.ErrMissingAuthor) {
+		name := author
+		if name == "" {
+			name = os.Getenv("USER")
+		}
+		signature := &object.Signature{Name: name, When: time.Now()}
+		_, err = worktree.Commit(message, &git.CommitOptions{Author: signature})
+	}
+	if err != nil {
+		return &gitError{Op: "commit in", Repo: projectPath, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// hookResult is the outcome of a single hook command.
+type hookResult struct {
+	stage    string
+	command  string
+	dir      string
+	exitCode int
+	duration time.Duration
+	stdout   []byte
+	stderr   []byte
+	err      error
+	// ignored is set when the hook failed but has continue_on_error.
+	ignored bool
+}
+
+// status returns a one-word description of the result for the summary.
+func (r hookResult) status() string {
+	switch {
+	case r.err == nil:
+		return "ok"
+	case r.ignored:
+		return "ignored"
+	default:
+		return "failed"
+	}
+}
+
+var (
+	// hookResults collects the outcome of every hook run by this process.
+	hookResults []hookResult
+	// hookLogPath is the generation log, created when the first hook has run.
+	hookLogPath string
+)
+
+// recordHookResult remembers the outcome of a hook for the summary and appends
+// it to the generation log.
+func recordHookResult(result hookResult) {
+	hookResults = append(hookResults, result)
+	if err := appendHookLog(result); err != nil {
+		fmt.Printf("Warning: could not write hook log: %v\n", err)
+	}
+}
+
+// appendHookLog writes the command, exit code, duration and output of a hook
+// to the generation log under the user cache directory.
+func appendHookLog(result hookResult) error {
+	if hookLogPath == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return err
+		}
+		logDir := filepath.Join(cacheDir, "forma", "logs")
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			return err
+		}
+		logFile, err := os.CreateTemp(logDir, "generate-"+time.Now().Format("20060102-150405")+"-*.log")
+		if err != nil {
+			return err
+		}
+		logFile.Close()
+		hookLogPath = logFile.Name()
+	}
+
+	logFile, err := os.OpenFile(hookLogPath, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "=== %s: %s\n", result.stage, result.command)
+	fmt.Fprintf(&entry, "dir: %s\n", result.dir)
+	fmt.Fprintf(&entry, "exit code: %d\n", result.exitCode)
+	fmt.Fprintf(&entry, "duration: %s\n", result.duration.Round(time.Millisecond))
+	if result.err != nil {
+		fmt.Fprintf(&entry, "error: %v\n", result.err)
+	}
+	for _, output := range []struct {
+		name    string
+		content []byte
+	}{{"stdout", result.stdout}, {"stderr", result.stderr}} {
+		fmt.Fprintf(&entry, "--- %s\n", output.name)
+		entry.Write(output.content)
+		if len(output.content) > 0 && output.content[len(output.content)-1] != '\n' {
+			entry.WriteString("\n")
+		}
+	}
+	entry.WriteString("\n")
+
+	_, err = logFile.WriteString(entry.String())
+	return err
+}
+
+// printHookSummary prints a table with one line per hook that ran and the
+// location of the generation log.
+func printHookSummary() {
+	if len(hookResults) == 0 {
+		return
+	}
+
+	fmt.Println("--- Hook summary ---")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tSTATUS\tEXIT\tTIME\tCOMMAND")
+	for _, result := range hookResults {
+		command := strings.Join(strings.Fields(result.command), " ")
+		if len(command) > 60 {
+			command = command[:57] + "..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", result.stage, result.status(), result.exitCode, formatDuration(result.duration), command)
+	}
+	w.Flush()
+	if hookLogPath != "" {
+		fmt.Printf("Full hook output written to %s\n", hookLogPath)
+	}
+}
+
+// formatDuration rounds a duration for display in the summary.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
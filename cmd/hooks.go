@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Stages at which template hooks run, in the order they happen.
+const (
+	stagePrePrompt  = "pre-prompt"
+	stagePreCreate  = "pre-create"
+	stagePostRender = "post-render"
+	stagePostCreate = "post-create"
+)
+
+// Hook is a single hook command. In template.yaml it is either a plain command
+// string or a mapping with the command under `run` and optional settings.
+type Hook struct {
+	Run string `yaml:"run"`
+	// When is a condition; the hook is skipped unless it evaluates to true.
+	When string `yaml:"when"`
+	// Dir is the working directory, relative to the directory of the stage.
+	Dir string `yaml:"dir"`
+	// Env holds extra environment variables for the command.
+	Env map[string]string `yaml:"env"`
+	// Timeout is a duration such as "30s" or "5m" after which the command is killed.
+	Timeout string `yaml:"timeout"`
+	// ContinueOnError lets generation go on when the command fails.
+	ContinueOnError bool   `yaml:"continue_on_error"`
+	Description     string `yaml:"description"`
+}
+
+// UnmarshalYAML accepts both the plain string and the mapping form of a hook.
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		h.Run = value.Value
+		return nil
+	}
+	type plain Hook
+	return value.Decode((*plain)(h))
+}
+
+// checkHooks validates the hooks of every stage.
+func checkHooks(config HooksConfig) error {
+	stages := map[string][]Hook{
+		"pre_prompt":  config.PrePrompt,
+		"pre_create":  config.PreCreate,
+		"post_render": config.PostRender,
+		"post_create": config.PostCreate,
+	}
+	for stage, hooks := range stages {
+		for i, hook := range hooks {
+			if strings.TrimSpace(hook.Run) == "" {
+				return fmt.Errorf("%s hook %d has no command to run", stage, i+1)
+			}
+			if hook.Timeout != "" {
+				if timeout, err := time.ParseDuration(hook.Timeout); err != nil || timeout <= 0 {
+					return fmt.Errorf("%s hook %d has invalid timeout %q", stage, i+1, hook.Timeout)
+				}
+			}
+			for name := range hook.Env {
+				if name == "" || strings.ContainsAny(name, "= ") {
+					return fmt.Errorf("%s hook %d has invalid environment variable name %q", stage, i+1, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// renderedHook is a hook whose command, directory and environment have been
+// rendered with the template data.
+type renderedHook struct {
+	Hook
+	env []string
+}
+
+// details summarizes the settings of a hook for the preview.
+func (h renderedHook) details() string {
+	var parts []string
+	if h.Dir != "" {
+		parts = append(parts, "in "+h.Dir)
+	}
+	for _, env := range h.env {
+		parts = append(parts, "env "+env)
+	}
+	if h.Timeout != "" {
+		parts = append(parts, "timeout "+h.Timeout)
+	}
+	if h.ContinueOnError {
+		parts = append(parts, "continue on error")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// runHooks renders the hooks of a stage, asks for confirmation and runs them
+// one by one in dir, stopping at the first failure.
+func runHooks(stage string, hooks []Hook, dir string, data TemplateData) error {
+	rendered, err := renderHooks(stage, hooks, data)
+	if err != nil {
+		return err
+	}
+	return execHooks(stage, rendered, dir)
+}
+
+// renderHooks renders hooks with the template data. Hooks whose condition is
+// false, or whose command renders to an empty string, are left out.
+func renderHooks(stage string, hooks []Hook, data TemplateData) ([]renderedHook, error) {
+	var rendered []renderedHook
+	for _, hook := range hooks {
+		if hook.When != "" {
+			ok, err := evalCondition(hook.When, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate %s hook condition: %w", stage, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		command, err := renderString("hook", hook.Run, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s hook command: %w", stage, err)
+		}
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+		r := renderedHook{Hook: hook}
+		r.Run = command
+
+		if hook.Dir != "" {
+			dir, err := renderString("hook", hook.Dir, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s hook directory: %w", stage, err)
+			}
+			if dir != "" && !filepath.IsLocal(dir) {
+				return nil, fmt.Errorf("%s hook directory %q must be inside the working directory", stage, dir)
+			}
+			r.Dir = dir
+		}
+
+		names := make([]string, 0, len(hook.Env))
+		for name := range hook.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value, err := renderString("hook", hook.Env[name], data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s hook environment variable %s: %w", stage, name, err)
+			}
+			r.env = append(r.env, name+"="+value)
+		}
+		rendered = append(rendered, r)
+	}
+	return rendered, nil
+}
+
+// runPostRenderHooks runs the post-render commands once for every generated
+// file, with the file's path available as {{ .File }}. Since empty commands are
+// skipped, a command can be limited to some files, e.g.
+// {{ if hasSuffix ".go" .File }}gofmt -w {{ .File | shquote }}{{ end }}.
+func runPostRenderHooks(hooks []Hook, dir string, files []plannedFile, data TemplateData) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	rendered, err := renderPostRenderHooks(hooks, files, data)
+	if err != nil {
+		return err
+	}
+	return execHooks(stagePostRender, rendered, dir)
+}
+
+// renderPostRenderHooks renders the post-render commands for every regular
+// file generated from the template.
+func renderPostRenderHooks(hooks []Hook, files []plannedFile, data TemplateData) ([]renderedHook, error) {
+	var rendered []renderedHook
+	for _, file := range files {
+		if file.isDir || file.symlink || file.path == recordFileName {
+			continue
+		}
+		fileData := data
+		fileData.file = filepath.ToSlash(file.path)
+		fileHooks, err := renderHooks(stagePostRender, hooks, fileData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.path, err)
+		}
+		rendered = append(rendered, fileHooks...)
+	}
+	return rendered, nil
+}
+
+// printHooks lists rendered hooks with their descriptions and settings.
+func printHooks(hooks []renderedHook) {
+	for i, hook := range hooks {
+		if hook.Description != "" {
+			fmt.Printf("  [%d] %s\n      %s%s\n", i+1, hook.Description, hook.Run, hook.details())
+		} else {
+			fmt.Printf("  [%d] %s%s\n", i+1, hook.Run, hook.details())
+		}
+	}
+}
+
+// execHooks previews rendered hooks, asks for confirmation and runs them.
+func execHooks(stage string, hooks []renderedHook, dir string) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	fmt.Printf("--- The following %s hooks will be executed ---\n", stage)
+	printHooks(hooks)
+
+	proceed, err := confirm(fmt.Sprintf("Do you want to proceed with executing all %s hooks?", stage))
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		if stage == stagePreCreate {
+			// Pre-create hooks guard generation, so skipping them means stopping.
+			return fmt.Errorf("%s hooks were not approved", stage)
+		}
+		fmt.Println("Aborted running hooks.")
+		return nil
+	}
+
+	fmt.Printf("--- Running %s hooks ---\n", stage)
+	for _, hook := range hooks {
+		if err := runHook(stage, hook, dir); err != nil {
+			if !hook.ContinueOnError {
+				printHookSummary()
+				return err
+			}
+			fmt.Printf("⚠️  %v; continuing.\n", err)
+		}
+	}
+
+	fmt.Println("--- Hooks finished successfully ---")
+	return nil
+}
+
+// runHook runs a single rendered hook with `sh -c`. Its output is captured for
+// the generation log and only shown when it fails, or as it runs with --verbose.
+func runHook(stage string, hook renderedHook, dir string) error {
+	if hook.Description != "" {
+		fmt.Printf("▶️ %s\n", hook.Description)
+	}
+	fmt.Printf("▶️ Running: %s\n", hook.Run)
+
+	ctx := context.Background()
+	if hook.Timeout != "" {
+		timeout, _ := time.ParseDuration(hook.Timeout) // validated by checkHooks
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Run)
+	cmd.Dir = filepath.Join(dir, hook.Dir)
+	cmd.Env = append(os.Environ(), hook.env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if verbose {
+		cmd.Stdout = io.MultiWriter(os.Stdout, &stdout)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	}
+	// Don't wait forever for output of processes left behind by a killed command.
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("hook command '%s' timed out after %s", hook.Run, hook.Timeout)
+	} else if err != nil {
+		err = fmt.Errorf("hook command '%s' failed: %w", hook.Run, err)
+	}
+
+	absDir, _ := filepath.Abs(cmd.Dir)
+	exitCode := 0
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+	recordHookResult(hookResult{
+		stage:    stage,
+		command:  hook.Run,
+		dir:      absDir,
+		exitCode: exitCode,
+		duration: time.Since(start),
+		stdout:   stdout.Bytes(),
+		stderr:   stderr.Bytes(),
+		err:      err,
+		ignored:  err != nil && hook.ContinueOnError,
+	})
+
+	if err != nil && !verbose {
+		os.Stdout.Write(stdout.Bytes())
+		os.Stderr.Write(stderr.Bytes())
+	}
+	return err
+}
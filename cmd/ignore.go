@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the file listing template paths that are never generated.
+const ignoreFileName = ".formaignore"
+
+// defaultIgnore is applied before the template's own .formaignore,
+// so a template can still re-include these paths with a "!" pattern.
+var defaultIgnore = []string{
+	".git/",
+	ignoreFileName,
+	"/" + originFileName,
+}
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher holds the ignore rules of a template in file order.
+type ignoreMatcher []ignoreRule
+
+// parseIgnore parses gitignore-style lines: blank lines and lines starting
+// with "#" are skipped, "!" re-includes a path and a trailing "/" only
+// matches directories.
+func parseIgnore(lines []string) ignoreMatcher {
+	var rules ignoreMatcher
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			// Escaped leading "#" or "!".
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// loadIgnore returns the built-in ignore rules followed by those in the
+// template's .formaignore file, if it has one.
+func loadIgnore(templatePath string) (ignoreMatcher, error) {
+	lines := append([]string{}, defaultIgnore...)
+
+	content, err := os.ReadFile(filepath.Join(templatePath, ignoreFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", ignoreFileName, err)
+	}
+	lines = append(lines, strings.Split(string(content), "\n")...)
+
+	return parseIgnore(lines), nil
+}
+
+// ignored reports whether a path relative to the template root is excluded.
+// As in gitignore, the last matching rule wins.
+func (m ignoreMatcher) ignored(relativePath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchGlob(rule.pattern, relativePath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
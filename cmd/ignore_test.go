@@ -0,0 +1,79 @@
+package cmd
+
+import "testing"
+
+func TestParseIgnore(t *testing.T) {
+	rules := parseIgnore([]string{
+		"# comment",
+		"",
+		"build/",
+		"!keep.log",
+		`\#hash`,
+		`\!bang`,
+		"trailing   ",
+		"!/",
+	})
+	want := []ignoreRule{
+		{pattern: "build", dirOnly: true},
+		{pattern: "keep.log", negate: true},
+		{pattern: "#hash"},
+		{pattern: "!bang"},
+		{pattern: "trailing"},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("parseIgnore returned %d rules, want %d: %+v", len(rules), len(want), rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"basename at any depth", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"anchored pattern", []string{"/debug.log"}, "a/debug.log", false, false},
+		{"anchored pattern at root", []string{"/debug.log"}, "debug.log", false, true},
+		{"directory rule matches directory", []string{"build/"}, "build", true, true},
+		{"directory rule skips file", []string{"build/"}, "build", false, false},
+		{"nested directory rule", []string{"node_modules/"}, "web/node_modules", true, true},
+		{"leading double star", []string{"**/cache"}, "a/b/cache", true, true},
+		{"middle double star", []string{"docs/**/draft.md"}, "docs/x/y/draft.md", false, true},
+		{"trailing double star", []string{"tmp/**"}, "tmp/a/b", false, true},
+		{"negation after rule", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"rule after negation", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"negation of other file", []string{"*.log", "!keep.log"}, "drop.log", false, true},
+		{"no rules", nil, "main.go", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIgnore(tt.lines).ignored(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultIgnore(t *testing.T) {
+	matcher := parseIgnore(defaultIgnore)
+	for path, isDir := range map[string]bool{
+		".git":         true,
+		ignoreFileName: false,
+		originFileName: false,
+		"sub/.git":     true,
+	} {
+		if !matcher.ignored(path, isDir) {
+			t.Errorf("%s is not ignored by default", path)
+		}
+	}
+	if matcher.ignored("sub/"+originFileName, false) {
+		t.Errorf("the origin file is only ignored at the template root")
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Values accepted by --on-conflict.
+const (
+	conflictAsk       = ""
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictFail      = "fail"
+)
+
+// intoTransaction writes a rendered template into an existing directory and
+// remembers what it changed, so a failure can restore the directory.
+type intoTransaction struct {
+	root      string
+	created   []string
+	originals map[string]fileEntry
+	// written lists the files that were created, replaced or merged.
+	written []plannedFile
+}
+
+// write creates or replaces a planned file, saving what was there before.
+func (t *intoTransaction) write(file plannedFile) error {
+	target := filepath.Join(t.root, file.path)
+	existing, err := readEntry(target)
+	if err != nil {
+		return err
+	}
+
+	if file.isDir {
+		if existing.exists {
+			return nil
+		}
+		t.created = append(t.created, target)
+		return os.MkdirAll(target, 0777)
+	}
+
+	if existing.exists {
+		t.originals[target] = existing
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	} else {
+		t.created = append(t.created, target)
+	}
+	t.written = append(t.written, file)
+	return writePlannedFile(t.root, file)
+}
+
+// rollback removes the files forma created and restores those it replaced.
+// Changes made by hooks are not undone.
+func (t *intoTransaction) rollback() {
+	for target, original := range t.originals {
+		if err := writeEntry(target, original); err != nil {
+			fmt.Printf("Warning: could not restore '%s': %v\n", target, err)
+		}
+	}
+	for i := len(t.created) - 1; i >= 0; i-- {
+		if err := os.Remove(t.created[i]); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Warning: could not remove '%s': %v\n", t.created[i], err)
+		}
+	}
+	fmt.Printf("Restored previous contents of '%s'.\n", t.root)
+}
+
+// findConflicts returns the planned files whose path already exists in root
+// with different content.
+func findConflicts(root string, files []plannedFile) ([]plannedFile, error) {
+	var conflicts []plannedFile
+	for _, file := range files {
+		if file.isDir || file.path == recordFileName {
+			continue
+		}
+		existing, err := readEntry(filepath.Join(root, file.path))
+		if err != nil {
+			return nil, err
+		}
+		planned := fileEntry{exists: true, symlink: file.symlink, content: file.content}
+		if existing.exists && !existing.equal(planned) {
+			conflicts = append(conflicts, file)
+		}
+	}
+	return conflicts, nil
+}
+
+// generateInto renders a template and writes it into an existing directory,
+// resolving each conflicting file according to policy. It returns the
+// transaction, so the caller can roll back if a later step such as a hook fails.
+func generateInto(templateName, templatePath, root string, config TemplateConfig, data TemplateData, policy string) (*intoTransaction, error) {
+	files, err := renderTemplate(templatePath, config, data)
+	if err != nil {
+		return nil, err
+	}
+	record, err := encodeProjectRecord(newProjectRecord(templateName, templatePath, data))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, plannedFile{path: recordFileName, content: record, mode: 0644})
+	sortPlannedFiles(files)
+
+	conflicts, err := findConflicts(root, files)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && (policy == conflictFail || (policy == conflictAsk && noInput)) {
+		var paths []string
+		for _, file := range conflicts {
+			paths = append(paths, file.path)
+		}
+		return nil, fmt.Errorf("%d file(s) already exist with different content: %s", len(conflicts), strings.Join(paths, ", "))
+	}
+
+	// Decide on every conflict before writing anything.
+	skip := make(map[string]bool)
+	merge := make(map[string]bool)
+	for _, file := range conflicts {
+		action := policy
+		if action == conflictAsk {
+			if action, err = askConflict(root, file); err != nil {
+				return nil, err
+			}
+		}
+		switch action {
+		case conflictSkip:
+			skip[file.path] = true
+		case "merge":
+			merge[file.path] = true
+		}
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create project directory: %w", err)
+	}
+	tx := &intoTransaction{root: root, originals: make(map[string]fileEntry)}
+	for _, file := range files {
+		var err error
+		switch {
+		case skip[file.path]:
+			fmt.Printf("  Kept:        %s\n", file.path)
+		case merge[file.path]:
+			err = tx.mergeWith(file)
+		default:
+			err = tx.write(file)
+		}
+		if err != nil {
+			tx.rollback()
+			return nil, fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+	}
+	return tx, nil
+}
+
+// mergeWith combines an existing file with the template version, leaving
+// conflict markers around the lines that differ.
+func (t *intoTransaction) mergeWith(file plannedFile) error {
+	target := filepath.Join(t.root, file.path)
+	existing, err := readEntry(target)
+	if err != nil {
+		return err
+	}
+	t.originals[target] = existing
+
+	binary := existing.symlink || file.symlink || isBinary(existing.content) || isBinary(file.content)
+	if binary {
+		// mergeFile saves the template version next to the file instead.
+		t.created = append(t.created, target+".rej")
+	}
+
+	theirs := fileEntry{exists: true, symlink: file.symlink, content: file.content, mode: file.mode}
+	clean, err := mergeFile(target, existing, fileEntry{exists: true}, theirs)
+	if err != nil {
+		return err
+	}
+	t.written = append(t.written, file)
+	if clean {
+		fmt.Printf("  Merged:      %s\n", file.path)
+	} else if binary {
+		fmt.Printf("  Conflict:    %s (template version saved as %s.rej)\n", file.path, file.path)
+	} else {
+		fmt.Printf("  Conflict:    %s (resolve the conflict markers)\n", file.path)
+	}
+	return nil
+}
+
+// askConflict prompts for how to handle a file that already exists.
+func askConflict(root string, file plannedFile) (string, error) {
+	for {
+		fmt.Printf("'%s' already exists. [k]eep, [o]verwrite, [d]iff or [m]erge? ", file.path)
+		var response string
+		if _, err := fmt.Scanln(&response); errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("no answer for conflicting file %s", file.path)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "k", "keep":
+			return conflictSkip, nil
+		case "o", "overwrite":
+			return conflictOverwrite, nil
+		case "m", "merge":
+			return "merge", nil
+		case "d", "diff":
+			existing, err := readEntry(filepath.Join(root, file.path))
+			if err != nil {
+				return "", err
+			}
+			if existing.symlink || file.symlink || isBinary(existing.content) || isBinary(file.content) {
+				fmt.Println("Binary files or symlinks differ")
+				continue
+			}
+			fmt.Print(unifiedDiff("existing/"+file.path, "template/"+file.path, splitLines(existing.content), splitLines(file.content)))
+		}
+	}
+}
@@ -2,11 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"bytes"
-	"os/exec"
-	"text/template"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -16,73 +14,43 @@ import (
 
 // HooksConfig holds commands to be run at different stages.
 type HooksConfig struct {
-	PostCreate []string `yaml:"post_create"`
+	// PrePrompt runs in the current directory before any variable is asked for.
+	PrePrompt []Hook `yaml:"pre_prompt"`
+	// PreCreate runs in the current directory once all answers are known and
+	// before any file is written; a failing command aborts generation.
+	PreCreate []Hook `yaml:"pre_create"`
+	// PostRender runs once for every generated file, which is available as {{ .File }}.
+	PostRender []Hook `yaml:"post_render"`
+	PostCreate []Hook `yaml:"post_create"`
 }
 
 // TemplateConfig matches the structure of the template.yaml file.
 type TemplateConfig struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Variables   []Variable  `yaml:"variables"`
 	Hooks       HooksConfig `yaml:"hooks"`
-}
-
-// listTemplatesCmd represents the list command
-func runHooks(commands []string, projectPath string, data TemplateData) error {
-	if len(commands) == 0 {
-		return nil
-	}
-
-	fmt.Println("--- The following post-creation hooks will be executed ---")
-	for i, commandStr := range commands {
-		// Process the command string as a template for preview
-		tmpl, err := template.New("hook").Parse(commandStr)
-		if err != nil {
-			fmt.Printf("  [%d] (template parse error): %s\n", i+1, commandStr)
-			continue
-		}
-		var processedCmd bytes.Buffer
-		if err := tmpl.Execute(&processedCmd, data); err != nil {
-			fmt.Printf("  [%d] (template exec error): %s\n", i+1, commandStr)
-			continue
-		}
-		fmt.Printf("  [%d] %s\n", i+1, processedCmd.String())
-	}
-
-	fmt.Print("Do you want to proceed with executing all hooks? [y/n]: ")
-	var response string
-	_, err := fmt.Scanln(&response)
-	if err != nil || (strings.ToLower(strings.TrimSpace(response)) != "y") {
-		fmt.Println("Aborted running hooks.")
-		return nil
-	}
-
-	fmt.Println("--- Running post-creation hooks ---")
-	for _, commandStr := range commands {
-		tmpl, err := template.New("hook").Parse(commandStr)
-		if err != nil {
-			return fmt.Errorf("failed to parse hook command template: %w", err)
-		}
-
-		var processedCmd bytes.Buffer
-		if err := tmpl.Execute(&processedCmd, data); err != nil {
-			return fmt.Errorf("failed to execute hook command template: %w", err)
-		}
-
-		command := processedCmd.String()
-		fmt.Printf("▶️ Running: %s\n", command)
 
-		cmd := exec.Command("sh", "-c", command)
-		cmd.Dir = projectPath
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("hook command '%s' failed: %w", command, err)
-		}
-	}
+	// Files maps glob patterns to conditions deciding whether matching paths are generated.
+	Files map[string]string `yaml:"files"`
+	// CopyWithoutRender lists glob patterns of files copied byte-for-byte.
+	CopyWithoutRender []string `yaml:"copy_without_render"`
+	// Modes maps glob patterns to octal permissions overriding the source file modes.
+	Modes map[string]string `yaml:"modes"`
+	// Delimiters replaces the default {{ }} action delimiters for the whole template.
+	Delimiters []string `yaml:"delimiters"`
+	// FileDelimiters maps glob patterns to delimiters used for matching files only.
+	FileDelimiters map[string][]string `yaml:"file_delimiters"`
+	// Git initialises a repository in new projects, after the post-create hooks.
+	Git GitConfig `yaml:"git"`
+}
 
-	fmt.Println("--- Hooks finished successfully ---")
-	return nil
+// GitConfig defines the repository created in a new project.
+type GitConfig struct {
+	// Init creates a git repository in the project.
+	Init bool `yaml:"init"`
+	// Commit is the message of an initial commit of all files; empty skips the commit.
+	Commit string `yaml:"commit"`
 }
 
 // listCmd represents the list command
@@ -109,7 +77,8 @@ var listCmd = &cobra.Command{
 		foundTemplates := 0
 
 		for _, entry := range entries {
-			if entry.IsDir() {
+			// Linked templates are symlinks to directories.
+			if entry.IsDir() || entry.Type()&fs.ModeSymlink != 0 {
 				templateName := entry.Name()
 				configPath := filepath.Join(templatesPath, templateName, "template.yaml")
 
@@ -0,0 +1,135 @@
+package cmd
+
+import "strings"
+
+// Conflict markers written around lines changed differently by the project
+// and the template, in the style of `git merge-file`.
+const (
+	conflictOurs   = "<<<<<<< project\n"
+	conflictSep    = "=======\n"
+	conflictTheirs = ">>>>>>> template (new)\n"
+)
+
+// merge3 merges the changes from base to theirs into ours, line by line. Lines
+// changed on both sides in different ways are surrounded by conflict markers.
+// It reports whether the merge was clean.
+func merge3(ours, base, theirs []byte) ([]byte, bool) {
+	a, o, b := mergeLines(ours), mergeLines(base), mergeLines(theirs)
+	inA, inB := matchLines(o, a), matchLines(o, b)
+
+	var merged strings.Builder
+	clean := true
+	i, j, k := 0, 0, 0
+	for i < len(o) || j < len(a) || k < len(b) {
+		// A base line kept in place on both sides is copied as it is.
+		if i < len(o) && inA[i] == j && inB[i] == k {
+			merged.WriteString(o[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Otherwise the chunk runs until the next base line both sides kept.
+		next := i
+		for next < len(o) && (inA[next] < 0 || inB[next] < 0) {
+			next++
+		}
+		endA, endB := len(a), len(b)
+		if next < len(o) {
+			endA, endB = inA[next], inB[next]
+		}
+		if !mergeChunk(&merged, a[j:endA], o[i:next], b[k:endB]) {
+			clean = false
+		}
+		i, j, k = next, endA, endB
+	}
+	return []byte(merged.String()), clean
+}
+
+// mergeChunk writes the merge of a chunk changed on one or both sides and
+// reports whether it merged without conflict.
+func mergeChunk(merged *strings.Builder, ours, base, theirs []string) bool {
+	switch {
+	case equalLines(ours, base), equalLines(ours, theirs):
+		writeLines(merged, theirs)
+		return true
+	case equalLines(theirs, base):
+		writeLines(merged, ours)
+		return true
+	}
+
+	// Lines both sides agree on are kept outside the conflict markers.
+	prefix := 0
+	for prefix < len(ours) && prefix < len(theirs) && ours[prefix] == theirs[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ours)-prefix && suffix < len(theirs)-prefix &&
+		ours[len(ours)-1-suffix] == theirs[len(theirs)-1-suffix] {
+		suffix++
+	}
+	writeLines(merged, ours[:prefix])
+	merged.WriteString(conflictOurs)
+	writeConflictLines(merged, ours[prefix:len(ours)-suffix])
+	merged.WriteString(conflictSep)
+	writeConflictLines(merged, theirs[prefix:len(theirs)-suffix])
+	merged.WriteString(conflictTheirs)
+	writeLines(merged, ours[len(ours)-suffix:])
+	return false
+}
+
+// mergeLines splits content into lines that keep their line ending, so a
+// merge reproduces the content exactly.
+func mergeLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchLines returns, for every line of base, the index of the line of other
+// it is kept as, or -1 if it was deleted.
+func matchLines(base, other []string) []int {
+	match := make([]int, len(base))
+	i, j := 0, 0
+	for _, op := range diffLines(base, other) {
+		switch op.kind {
+		case ' ':
+			match[i] = j
+			i, j = i+1, j+1
+		case '-':
+			match[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	return match
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(merged *strings.Builder, lines []string) {
+	for _, line := range lines {
+		merged.WriteString(line)
+	}
+}
+
+// writeConflictLines writes lines inside conflict markers, ending the last
+// one with a newline so the next marker starts on its own line.
+func writeConflictLines(merged *strings.Builder, lines []string) {
+	writeLines(merged, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		merged.WriteString("\n")
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		ours, base, theirs string
+		want               string
+		clean              bool
+	}{
+		{
+			name:   "unchanged",
+			ours:   "a\nb\n",
+			base:   "a\nb\n",
+			theirs: "a\nb\n",
+			want:   "a\nb\n",
+			clean:  true,
+		},
+		{
+			name:   "template change only",
+			ours:   "a\nb\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\nB\nc\n",
+			clean:  true,
+		},
+		{
+			name:   "project change only",
+			ours:   "a\nB\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nB\nc\n",
+			clean:  true,
+		},
+		{
+			name:   "changes to different lines",
+			ours:   "A\nb\nc\nd\ne\n",
+			base:   "a\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+			clean:  true,
+		},
+		{
+			name:   "insertions at both ends",
+			ours:   "top\na\nb\n",
+			base:   "a\nb\n",
+			theirs: "a\nb\nbottom\n",
+			want:   "top\na\nb\nbottom\n",
+			clean:  true,
+		},
+		{
+			name:   "same change on both sides",
+			ours:   "a\nX\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\nX\nc\n",
+			want:   "a\nX\nc\n",
+			clean:  true,
+		},
+		{
+			name:   "deletion and unrelated change",
+			ours:   "a\nc\nd\n",
+			base:   "a\nb\nc\nd\n",
+			theirs: "a\nb\nc\nD\n",
+			want:   "a\nc\nD\n",
+			clean:  true,
+		},
+		{
+			name:   "conflicting changes",
+			ours:   "a\nours\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\ntheirs\nc\n",
+			want:   "a\n<<<<<<< project\nours\n=======\ntheirs\n>>>>>>> template (new)\nc\n",
+			clean:  false,
+		},
+		{
+			name:   "conflict keeps shared lines outside the markers",
+			ours:   "a\nsame\nours\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\nsame\ntheirs\nc\n",
+			want:   "a\nsame\n<<<<<<< project\nours\n=======\ntheirs\n>>>>>>> template (new)\nc\n",
+			clean:  false,
+		},
+		{
+			name:   "deleted by project, changed by template",
+			ours:   "a\nc\n",
+			base:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\n<<<<<<< project\n=======\nB\n>>>>>>> template (new)\nc\n",
+			clean:  false,
+		},
+		{
+			name:   "added on both sides without a base",
+			ours:   "x\n",
+			base:   "",
+			theirs: "y\n",
+			want:   "<<<<<<< project\nx\n=======\ny\n>>>>>>> template (new)\n",
+			clean:  false,
+		},
+		{
+			name:   "missing final newline",
+			ours:   "a\nb",
+			base:   "a\nb",
+			theirs: "A\nb",
+			want:   "A\nb",
+			clean:  true,
+		},
+		{
+			name:   "conflict on a line without final newline",
+			ours:   "a\nours",
+			base:   "a\nb",
+			theirs: "a\ntheirs",
+			want:   "a\n<<<<<<< project\nours\n=======\ntheirs\n>>>>>>> template (new)\n",
+			clean:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, clean := merge3([]byte(tt.ours), []byte(tt.base), []byte(tt.theirs))
+			if string(got) != tt.want || clean != tt.clean {
+				t.Errorf("merge3() = %q, %v, want %q, %v", got, clean, tt.want, tt.clean)
+			}
+		})
+	}
+}
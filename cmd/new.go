@@ -9,15 +9,64 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
-var author string
+var (
+	author      string
+	answersFile string
+	setValues   []string
+	assumeYes   bool
+	noInput     bool
+	dryRun      bool
+	keepFailed  bool
+	intoDir     string
+	onConflict  string
+	verbose     bool
+)
 
+// TemplateData holds the values available to templates when rendering.
 type TemplateData struct {
 	ProjectName string
 	Author      string
 	Timestamp   string
+	// Variables holds the resolved values of the variables declared in template.yaml.
+	Variables map[string]interface{}
+	// delims overrides the default {{ }} action delimiters when set.
+	delims []string
+	// file is the path of the file a post-render hook runs for.
+	file string
+}
+
+// leftDelim returns the opening action delimiter in use.
+func (d TemplateData) leftDelim() string {
+	if len(d.delims) == 2 {
+		return d.delims[0]
+	}
+	return "{{"
+}
+
+// rightDelim returns the closing action delimiter in use.
+func (d TemplateData) rightDelim() string {
+	if len(d.delims) == 2 {
+		return d.delims[1]
+	}
+	return "}}"
+}
+
+// context flattens the built-in values and template variables into a single map,
+// so both can be referenced directly, e.g. {{ .ProjectName }} or {{ .ModulePath }}.
+func (d TemplateData) context() map[string]interface{} {
+	ctx := make(map[string]interface{}, len(d.Variables)+3)
+	for name, value := range d.Variables {
+		ctx[name] = value
+	}
+	ctx["ProjectName"] = d.ProjectName
+	ctx["Author"] = d.Author
+	ctx["Timestamp"] = d.Timestamp
+	if d.file != "" {
+		ctx["File"] = d.file
+	}
+	return ctx
 }
 
 var newCmd = &cobra.Command{
@@ -27,31 +76,118 @@ var newCmd = &cobra.Command{
 For example:
 forma new go-api my-awesome-project`,
 	Example: `  forma new go-api my-awesome-project
-  forma new python-app my-python-project --author "Jane Doe"`,
-	// This makes sure the user provides exactly two arguments.
+  forma new python-app my-python-project --author "Jane Doe"
+  forma new go-api my-service --author ci --answers answers.yaml --set Port=9090 --yes --no-input
+  forma new go-api my-service --author "Jane Doe" --dry-run
+  forma new go-api --author "Jane Doe" --into . --on-conflict=skip`,
+	Args: cobra.MaximumNArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		var templateName, projectName, finalAuthor string
 
-		// If we have all required info, run directly.
-		if len(args) == 2 && author != "" {
+		answers, err := loadAnswers(answersFile, setValues)
+		if err != nil {
+			fmt.Printf("Error loading answers: %v\n", err)
+			os.Exit(1)
+		}
+		switch onConflict {
+		case conflictAsk, conflictSkip, conflictOverwrite, conflictFail:
+		default:
+			fmt.Printf("Error: invalid --on-conflict value '%s', expected skip, overwrite or fail.\n", onConflict)
+			os.Exit(1)
+		}
+
+		// Arguments and flags take precedence over the answers file.
+		if len(args) > 0 {
 			templateName = args[0]
+		}
+		if len(args) > 1 {
 			projectName = args[1]
-			finalAuthor = author
 		} else {
-			// No arguments, launch the TUI!
-			m := initialModel(author)
-			p := tea.NewProgram(m)
-			finalModel, err := p.Run()
-			if err != nil {
-				fmt.Println("Error running program:", err)
+			projectName = stringAnswer(answers, "ProjectName")
+		}
+		if projectName == "" && intoDir != "" {
+			// When generating into a directory, its name is the natural project name.
+			if absInto, err := filepath.Abs(intoDir); err == nil {
+				projectName = filepath.Base(absInto)
+			}
+		}
+		finalAuthor = author
+		if finalAuthor == "" {
+			finalAuthor = stringAnswer(answers, "Author")
+		}
+
+		prePromptDone := false
+
+		// If we have all required info, run directly.
+		if noInput || (templateName != "" && projectName != "" && finalAuthor != "") {
+			var missing []string
+			if templateName == "" {
+				missing = append(missing, "template")
+			}
+			if projectName == "" {
+				missing = append(missing, "project name")
+			}
+			if finalAuthor == "" {
+				missing = append(missing, "author")
+			}
+			if len(missing) > 0 {
+				fmt.Printf("Error: missing %s; provide them as arguments, flags or answers.\n", strings.Join(missing, ", "))
 				os.Exit(1)
 			}
+		} else if templateName != "" && !templateNeedsInput(templateName, finalAuthor, projectName, answers) {
+			// Nothing is left to ask for the given template.
+		} else {
+			// Values are missing, launch the TUI!
+			// Values already given are not asked for again.
+			preset := make(map[string]interface{}, len(answers)+1)
+			for name, value := range answers {
+				preset[name] = value
+			}
+			delete(preset, "ProjectName")
+			if projectName != "" {
+				preset["ProjectName"] = projectName
+			}
+			if templateName != "" && !dryRun {
+				// Run them before the TUI starts, as it queries the terminal and
+				// the reply would be read as the answer to their confirmation.
+				if err := runPrePromptHooks(templateName, finalAuthor, answers); err != nil {
+					fmt.Printf("Error running pre-prompt hooks: %v\n", err)
+					os.Exit(1)
+				}
+				prePromptDone = true
+			}
+			m := initialModel(finalAuthor, projectName, preset)
+			if templateName != "" {
+				m = m.startAt(templateName)
+			}
+			var final model
+			for {
+				p := tea.NewProgram(m)
+				finalModel, err := p.Run()
+				if err != nil {
+					fmt.Println("Error running program:", err)
+					os.Exit(1)
+				}
 
-			// Cast the final model to our model type
-			final, ok := finalModel.(model)
-			if !ok {
-				fmt.Println("Error: unexpected model type returned from TUI.")
-				return
+				// Cast the final model to our model type
+				var ok bool
+				final, ok = finalModel.(model)
+				if !ok {
+					fmt.Println("Error: unexpected model type returned from TUI.")
+					return
+				}
+				if !final.prePrompt {
+					break
+				}
+
+				// The TUI stops after a template with pre-prompt hooks is chosen,
+				// so they can run in the terminal before its questions are asked.
+				if err := runPrePromptHooks(final.template, finalAuthor, answers); err != nil {
+					fmt.Printf("Error running pre-prompt hooks: %v\n", err)
+					os.Exit(1)
+				}
+				prePromptDone = true
+				m = final.resume()
 			}
 
 			// Check if there was an error in the TUI
@@ -61,7 +197,7 @@ forma new go-api my-awesome-project`,
 			}
 
 			// Check if the user quit without confirming
-			if final.template == "" || final.projectName == "" {
+			if !final.done {
 				fmt.Println("Aborted.")
 				return
 			}
@@ -69,84 +205,235 @@ forma new go-api my-awesome-project`,
 			templateName = final.template
 			projectName = final.projectName
 			finalAuthor = final.author
+			for name, value := range final.answers {
+				answers[name] = value
+			}
 		}
 
 		systemTemplatesPath, err := getTemplatesPath()
 		if err != nil {
 			fmt.Printf("Error getting templates path: %v\n", err)
-			return
+			os.Exit(1)
 		}
 		templatePath := filepath.Join(systemTemplatesPath, templateName)
 
 		// 1. Read and parse the template.yaml file to get hook info
-		configPath := filepath.Join(templatePath, "template.yaml")
-		yamlFile, err := os.ReadFile(configPath)
+		templateConfig, err := loadTemplateConfig(templatePath)
+		if err != nil {
+			fmt.Printf("Error loading template: %v\n", err)
+			os.Exit(1)
+		}
+		if err := disableUntrustedHooks(templateName, &templateConfig); err != nil {
+			fmt.Printf("Error checking template trust: %v\n", err)
+			os.Exit(1)
+		}
+		if !prePromptDone && !dryRun {
+			if err := runPrePromptHooks(templateName, finalAuthor, answers); err != nil {
+				fmt.Printf("Error running pre-prompt hooks: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if noInput {
+			if missing := missingVariables(templateConfig.Variables, answers); len(missing) > 0 {
+				fmt.Printf("Error: no value for %s and --no-input is set.\n", strings.Join(missing, ", "))
+				os.Exit(1)
+			}
+		}
+		variables, err := resolveVariables(templateConfig.Variables, answers)
 		if err != nil {
-			fmt.Printf("Error reading template config: %v\n", err)
+			fmt.Printf("Error resolving template variables: %v\n", err)
+			os.Exit(1)
+		}
+
+		data := TemplateData{
+			ProjectName: projectName,
+			Author:      finalAuthor,
+			Timestamp:   time.Now().Format(time.RFC822),
+			Variables:   variables,
+			delims:      templateConfig.Delimiters,
+		}
+		projectPath := filepath.Join(".", projectName)
+		if intoDir != "" {
+			projectPath = intoDir
+		}
+
+		if dryRun {
+			if err := printDryRun(templateName, templatePath, projectPath, templateConfig, data); err != nil {
+				fmt.Printf("Error rendering template: %v\n", err)
+				os.Exit(1)
+			}
 			return
 		}
 
-		var templateConfig TemplateConfig
-		if err := yaml.Unmarshal(yamlFile, &templateConfig); err != nil {
-			fmt.Printf("Error parsing template config: %v\n", err)
+		// Pre-create hooks run before anything is written, so they can abort generation.
+		if err := runHooks(stagePreCreate, templateConfig.Hooks.PreCreate, ".", data); err != nil {
+			fmt.Printf("Error running pre-create hooks: %v\n", err)
+			os.Exit(1)
+		}
+
+		if intoDir != "" {
+			fmt.Printf("Generating project '%s' from template '%s' into '%s'\n", projectName, templateName, intoDir)
+			tx, err := generateInto(templateName, templatePath, intoDir, templateConfig, data, onConflict)
+			if err != nil {
+				fmt.Printf("Error creating project from template: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runPostRenderHooks(templateConfig.Hooks.PostRender, projectPath, tx.written, data); err != nil {
+				fmt.Printf("Error running post-render hooks: %v\n", err)
+				tx.rollback()
+				os.Exit(1)
+			}
+			if err := runHooks(stagePostCreate, templateConfig.Hooks.PostCreate, projectPath, data); err != nil {
+				fmt.Printf("Error running post-create hooks: %v\n", err)
+				tx.rollback()
+				os.Exit(1)
+			}
+			printHookSummary()
+			fmt.Println("✅ Project created successfully!")
 			return
 		}
 
 		fmt.Printf("Creating a new project '%s' from template '%s'\n", projectName, templateName)
 
-		// Create the new project directory.
-		projectPath := filepath.Join(".", projectName)
+		// Check whether the project directory already exists.
 		_, err = os.Stat(projectPath)
 		if err == nil {
 			// If the project directory already exists, prompt the user for confirmation to overwrite it.
-			fmt.Printf("Project directory '%s' already exists. Do you want to overwrite it? (y/n): ", projectName)
-			var response string
-			fmt.Scanln(&response)
-			normalized := strings.ToLower(strings.TrimSpace(response))
-			if normalized != "y" {
+			overwrite, err := confirm(fmt.Sprintf("Project directory '%s' already exists. Do you want to overwrite it?", projectName))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if !overwrite {
 				fmt.Println("Project creation aborted.")
 				return
-			} else {
-				// If the user confirms, remove the existing directory.
-				err = os.RemoveAll(projectPath)
-				if err != nil {
-					fmt.Printf("Error removing existing project directory: %v\n", err)
-					return
-				}
 			}
+			// The existing directory is only replaced once generation succeeds.
 		} else if !os.IsNotExist(err) {
 			// If there was an error other than "not found", print it and exit.
 			fmt.Printf("Error checking project directory: %v\n", err)
-			return
+			os.Exit(1)
 		}
 
-		data := TemplateData{
-			ProjectName: projectName,
-			Author:      finalAuthor,
-			Timestamp:   time.Now().Format(time.RFC822),
+		// Generate the project in a staging directory next to its final location.
+		tx, err := beginProject(projectPath)
+		if err != nil {
+			fmt.Printf("Error creating project directory: %v\n", err)
+			os.Exit(1)
 		}
 
-
 		// Copy the entire template structure.
-		err = copyTemplate(templatePath, projectPath, data)
+		files, err := copyTemplate(templatePath, tx.stagingPath, templateConfig, data)
 		if err != nil {
 			fmt.Printf("Error creating project from template: %v\n", err)
-			return
+			tx.rollback(keepFailed)
+			os.Exit(1)
 		}
 
-		// 2. Run the post-create hooks
-		if len(templateConfig.Hooks.PostCreate) > 0 {
-			if err := runHooks(templateConfig.Hooks.PostCreate, projectPath, data); err != nil {
-				fmt.Printf("Error running post-create hooks: %v\n", err)
-				return
+		// Post-render hooks, such as formatters, run on the staged files.
+		if err := runPostRenderHooks(templateConfig.Hooks.PostRender, tx.stagingPath, files, data); err != nil {
+			fmt.Printf("Error running post-render hooks: %v\n", err)
+			tx.rollback(keepFailed)
+			os.Exit(1)
+		}
+
+		// Record how the project was generated, before hooks such as `git add .` run.
+		record := newProjectRecord(templateName, templatePath, data)
+		if err := writeProjectRecord(tx.stagingPath, record); err != nil {
+			fmt.Printf("Error recording project answers: %v\n", err)
+			tx.rollback(keepFailed)
+			os.Exit(1)
+		}
+
+		// Move the project into place, keeping any replaced directory as a backup.
+		if err := tx.commit(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			tx.rollback(keepFailed)
+			os.Exit(1)
+		}
+
+		// 2. Run the post-create hooks in the final location, since tools such as
+		// Python virtual environments record absolute paths.
+		if err := runHooks(stagePostCreate, templateConfig.Hooks.PostCreate, projectPath, data); err != nil {
+			fmt.Printf("Error running post-create hooks: %v\n", err)
+			tx.rollback(keepFailed)
+			os.Exit(1)
+		}
+
+		// 3. Commit the project last, so it includes files created by the hooks.
+		if templateConfig.Git.Init {
+			if err := gitInitialCommit(projectPath, templateConfig.Git.Commit, data.Author); err != nil {
+				fmt.Printf("Error creating git repository: %v\n", err)
+				tx.rollback(keepFailed)
+				os.Exit(1)
 			}
 		}
+		tx.finish()
 
+		printHookSummary()
 		fmt.Println("✅ Project created successfully!")
 	},
 }
 
+// templateNeedsInput reports whether the TUI has questions to ask for a
+// template given on the command line. If the template cannot be loaded, there
+// is nothing to ask and the error is reported when it is loaded to generate.
+func templateNeedsInput(templateName, author, projectName string, answers map[string]interface{}) bool {
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		return false
+	}
+	config, err := loadTemplateConfig(filepath.Join(templatesPath, templateName))
+	if err != nil {
+		return false
+	}
+	return len(buildPrompts(config, author, projectName, answers)) > 0
+}
+
+// runPrePromptHooks runs a template's pre-prompt hooks in the current directory,
+// with the values known before any question is asked.
+func runPrePromptHooks(templateName, author string, answers map[string]interface{}) error {
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		return err
+	}
+	config, err := loadTemplateConfig(filepath.Join(templatesPath, templateName))
+	if err != nil {
+		return err
+	}
+	if trust, err := hooksTrust(templateName, config.Hooks); err != nil || trust != hooksTrusted {
+		// Untrusted hooks are reported once the template is loaded for generation.
+		return err
+	}
+
+	// Variables not answered yet are empty, so only unknown names are errors.
+	variables := make(map[string]interface{}, len(answers)+len(config.Variables))
+	for _, v := range config.Variables {
+		variables[v.Name] = ""
+	}
+	for name, value := range answers {
+		variables[name] = value
+	}
+	data := TemplateData{
+		ProjectName: stringAnswer(answers, "ProjectName"),
+		Author:      author,
+		Timestamp:   time.Now().Format(time.RFC822),
+		Variables:   variables,
+		delims:      config.Delimiters,
+	}
+	return runHooks(stagePrePrompt, config.Hooks.PrePrompt, ".", data)
+}
+
 func init() {
 	rootCmd.AddCommand(newCmd)
 	newCmd.Flags().StringVarP(&author, "author", "a", "", "Author of the project")
+	newCmd.Flags().StringVar(&answersFile, "answers", "", "YAML file with answers for the template variables")
+	newCmd.Flags().StringArrayVar(&setValues, "set", nil, "Set a template variable (key=value), can be repeated")
+	newCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to all confirmation prompts")
+	newCmd.Flags().BoolVar(&noInput, "no-input", false, "Never prompt; fail if a required value is missing")
+	newCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be generated without writing files or running hooks")
+	newCmd.Flags().BoolVar(&keepFailed, "keep-failed", false, "Keep the partial output of a failed generation for debugging")
+	newCmd.Flags().StringVar(&intoDir, "into", "", "Generate into an existing directory instead of creating a new one")
+	newCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show the output of hooks as they run")
+	newCmd.Flags().StringVar(&onConflict, "on-conflict", "", "With --into, handle existing files by 'skip', 'overwrite' or 'fail' instead of asking")
 }
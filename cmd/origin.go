@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// originFileName is written into templates installed with `forma add` and
+// records where they came from.
+const originFileName = ".forma-source.yaml"
+
+// templateOrigin describes where an installed template came from: the
+// repository, the ref it is pinned to, the subdirectory holding the template
+// and the commit that was installed.
+type templateOrigin struct {
+	URL      string `yaml:"url"`
+	Ref      string `yaml:"ref,omitempty"`
+	Subdir   string `yaml:"subdir,omitempty"`
+	Revision string `yaml:"revision,omitempty"`
+}
+
+// readTemplateOrigin reads the origin file of an installed template. It
+// reports false if the template has none.
+func readTemplateOrigin(templatePath string) (templateOrigin, bool, error) {
+	var origin templateOrigin
+	content, err := os.ReadFile(filepath.Join(templatePath, originFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return origin, false, nil
+	}
+	if err != nil {
+		return origin, false, fmt.Errorf("failed to read %s: %w", originFileName, err)
+	}
+	if err := yaml.Unmarshal(content, &origin); err != nil {
+		return origin, false, fmt.Errorf("failed to parse %s: %w", originFileName, err)
+	}
+	return origin, true, nil
+}
+
+// writeTemplateOrigin writes the origin file into an installed template.
+func writeTemplateOrigin(templatePath string, origin templateOrigin) error {
+	var content bytes.Buffer
+	content.WriteString("# Written by forma add. Records where this template was installed from.\n")
+
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(origin); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", originFileName, err)
+	}
+	if err := os.WriteFile(filepath.Join(templatePath, originFileName), content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", originFileName, err)
+	}
+	return excludeFromGit(templatePath, originFileName)
+}
+
+// excludeFromGit adds a file to the local exclude list of a git checkout, so
+// it doesn't show up as untracked. It does nothing for other directories.
+func excludeFromGit(repoPath, name string) error {
+	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
+		return nil
+	}
+	excludePath := filepath.Join(repoPath, ".git", "info", "exclude")
+	content, err := os.ReadFile(excludePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	pattern := "/" + name
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return err
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, pattern+"\n"...)
+	return os.WriteFile(excludePath, content, 0644)
+}
+
+// gitRevision returns the commit checked out in a git repository.
+func gitRevision(repoPath string) (string, error) {
+	repo, err := gitOpen(repoPath)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the checked out revision: %w", err)
+	}
+	return head.Hash().String(), nil
+}
+
+// copyDir copies a directory tree, keeping file modes and symlinks. Git
+// metadata is left out.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" && relativePath != "." {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, relativePath)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, content, info.Mode().Perm())
+		}
+	})
+}
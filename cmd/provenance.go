@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recordFileName is the provenance file written into every generated project.
+const recordFileName = ".forma-answers.yaml"
+
+// ProjectRecord describes how a project was generated: which template, from
+// where and at which revision, by which forma version, and with which answers.
+type ProjectRecord struct {
+	Template     string                 `yaml:"template"`
+	Source       string                 `yaml:"source,omitempty"`
+	Ref          string                 `yaml:"ref,omitempty"`
+	Subdir       string                 `yaml:"subdir,omitempty"`
+	Revision     string                 `yaml:"revision,omitempty"`
+	FormaVersion string                 `yaml:"forma_version"`
+	Created      string                 `yaml:"created"`
+	Answers      map[string]interface{} `yaml:"answers"`
+}
+
+// templateSource returns where a template was installed from. For templates
+// added with `forma add` this is read from their origin file; for other git
+// checkouts, the remote URL and current commit are used. Everything is empty
+// for templates that did not come from git.
+func templateSource(templatePath string) templateOrigin {
+	origin, _, _ := readTemplateOrigin(templatePath)
+
+	// Only look at the template's own repository, never at a parent one.
+	if _, err := os.Stat(filepath.Join(templatePath, ".git")); err != nil {
+		return origin
+	}
+
+	repo, err := gitOpen(templatePath)
+	if err != nil {
+		return origin
+	}
+	if origin.URL == "" {
+		origin.URL = gitRemoteURL(repo)
+	}
+	// The checkout may have moved since it was installed.
+	if head, err := repo.Head(); err == nil {
+		origin.Revision = head.Hash().String()
+	} else {
+		origin.Revision = ""
+	}
+	return origin
+}
+
+// newProjectRecord builds the provenance record for a project generated from
+// the template at templatePath.
+func newProjectRecord(templateName, templatePath string, data TemplateData) ProjectRecord {
+	origin := templateSource(templatePath)
+
+	answers := make(map[string]interface{}, len(data.Variables)+3)
+	for name, value := range data.Variables {
+		answers[name] = value
+	}
+	answers["ProjectName"] = data.ProjectName
+	answers["Author"] = data.Author
+	// The rendered value is kept as it is, so the project can be rendered
+	// again identically whatever the time zone.
+	answers["Timestamp"] = data.Timestamp
+
+	return ProjectRecord{
+		Template:     templateName,
+		Source:       origin.URL,
+		Ref:          origin.Ref,
+		Subdir:       origin.Subdir,
+		Revision:     origin.Revision,
+		FormaVersion: formaVersion(),
+		Created:      time.Now().UTC().Format(time.RFC3339),
+		Answers:      answers,
+	}
+}
+
+// encodeProjectRecord returns the content of the provenance file.
+func encodeProjectRecord(record ProjectRecord) ([]byte, error) {
+	var content bytes.Buffer
+	content.WriteString("# Generated by forma. Records the template and answers used to create this project.\n")
+
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(record); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", recordFileName, err)
+	}
+	return content.Bytes(), nil
+}
+
+// writeProjectRecord writes the provenance record into the project root.
+func writeProjectRecord(projectPath string, record ProjectRecord) error {
+	content, err := encodeProjectRecord(record)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(projectPath, recordFileName)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", recordFileName, err)
+	}
+	return nil
+}
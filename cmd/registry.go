@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// registriesFileName is the file next to the templates directory that lists
+// the registry indexes searched by `forma search` and `forma add <id>`.
+const registriesFileName = "registries.yaml"
+
+// registryURL is the index given with --registry, used instead of the configured ones.
+var registryURL string
+
+// registriesConfig is the content of the registries file.
+type registriesConfig struct {
+	Registries []string `yaml:"registries"`
+}
+
+// registryIndex is a catalog of templates, written in YAML or JSON.
+type registryIndex struct {
+	Templates []registryTemplate `yaml:"templates"`
+}
+
+// registryTemplate is a template listed in a registry index.
+type registryTemplate struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Tags        []string `yaml:"tags"`
+	// URL is a git repository, or a local directory or archive.
+	URL    string `yaml:"url"`
+	Ref    string `yaml:"ref"`
+	Subdir string `yaml:"subdir"`
+
+	// registry is the index the template was listed in.
+	registry string
+}
+
+// matches reports whether the ID, name, description or a tag contains term,
+// ignoring case.
+func (t registryTemplate) matches(term string) bool {
+	term = strings.ToLower(term)
+	fields := append([]string{t.ID, t.Name, t.Description}, t.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// registryIndexes returns the indexes to use: the one given with --registry,
+// or those listed in the registries file.
+func registryIndexes() ([]string, error) {
+	if registryURL != "" {
+		return []string{registryURL}, nil
+	}
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(filepath.Dir(templatesPath), registriesFileName)
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no registry configured; list index URLs under 'registries' in %s or pass --registry", configPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", registriesFileName, err)
+	}
+	var config registriesConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", registriesFileName, err)
+	}
+	if len(config.Registries) == 0 {
+		return nil, fmt.Errorf("no registry configured; list index URLs under 'registries' in %s or pass --registry", configPath)
+	}
+	return config.Registries, nil
+}
+
+// loadRegistries reads every configured index. A template listed in several
+// indexes is taken from the first one.
+func loadRegistries() ([]registryTemplate, error) {
+	indexes, err := registryIndexes()
+	if err != nil {
+		return nil, err
+	}
+	var templates []registryTemplate
+	seen := make(map[string]bool)
+	for _, index := range indexes {
+		listed, err := loadRegistryIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range listed {
+			if !seen[template.ID] {
+				seen[template.ID] = true
+				templates = append(templates, template)
+			}
+		}
+	}
+	return templates, nil
+}
+
+// loadRegistryIndex reads an index from an http(s) URL, a file:// URL or a
+// local path. Relative template URLs in a local index are resolved against
+// the index's directory.
+func loadRegistryIndex(location string) ([]registryTemplate, error) {
+	content, localDir, err := readRegistryIndex(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry %s: %w", location, err)
+	}
+
+	// JSON is valid YAML, so both formats are parsed the same way.
+	var index registryIndex
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse registry %s: %w", location, err)
+	}
+	for i := range index.Templates {
+		template := &index.Templates[i]
+		if template.ID == "" || template.URL == "" {
+			return nil, fmt.Errorf("registry %s: template %d needs an id and a url", location, i+1)
+		}
+		if !isRegistryID(template.ID) {
+			return nil, fmt.Errorf("registry %s: invalid template id '%s'", location, template.ID)
+		}
+		if localDir != "" && isRelativePath(template.URL) {
+			template.URL = filepath.Join(localDir, filepath.FromSlash(template.URL))
+		}
+		template.registry = location
+	}
+	return index.Templates, nil
+}
+
+// readRegistryIndex returns the content of an index and, for local indexes,
+// the directory holding it.
+func readRegistryIndex(location string) ([]byte, string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(location)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("server returned %s", resp.Status)
+		}
+		content, err := io.ReadAll(resp.Body)
+		return content, "", err
+	}
+
+	path := location
+	if strings.HasPrefix(location, "file://") {
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return nil, "", err
+		}
+		path = filepath.FromSlash(parsed.Path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := os.ReadFile(path)
+	return content, filepath.Dir(path), err
+}
+
+// findRegistryTemplate looks up a template by ID in the configured registries.
+func findRegistryTemplate(id string) (registryTemplate, error) {
+	templates, err := loadRegistries()
+	if err != nil {
+		return registryTemplate{}, err
+	}
+	for _, template := range templates {
+		if template.ID == id {
+			return template, nil
+		}
+	}
+	return registryTemplate{}, fmt.Errorf("template '%s' was not found in the registry; try 'forma search'", id)
+}
+
+// isRegistryID reports whether s can be a registry ID rather than a URL or a path.
+func isRegistryID(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\:@`)
+}
+
+// isRelativePath reports whether a template URL is a relative path rather
+// than a URL or an absolute path.
+func isRelativePath(s string) bool {
+	return !strings.Contains(s, "://") && !strings.Contains(s, "@") && !filepath.IsAbs(s)
+}
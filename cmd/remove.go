@@ -53,6 +53,16 @@ var removeCmd = &cobra.Command{
 			return
 		}
 
+		// Forget the approval, so a new template of the same name is not trusted.
+		if store, err := loadTrustStore(); err == nil {
+			if _, ok := store.Templates[templateName]; ok {
+				delete(store.Templates, templateName)
+				if err := store.save(); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
+			}
+		}
+
 		fmt.Printf("Successfully removed template '%s'.\n", templateName)
 	},
 }
@@ -3,12 +3,30 @@ package cmd
 import (
 	"embed"
 	"os"
+	"runtime/debug"
+
 	"github.com/spf13/cobra"
 )
 
 // This package-level variable will hold the filesystem passed from main.go.
 var embeddedTemplates embed.FS
 
+// version is set for release builds with
+// -ldflags "-X github.com/nunseik/forma/cmd.version=v1.2.3".
+var version string
+
+// formaVersion returns the release version, the module version for builds made
+// with `go install`, or "dev".
+func formaVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "forma",
@@ -32,6 +50,7 @@ func Execute(fs embed.FS) {
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.Version = formaVersion()
 }
 
 
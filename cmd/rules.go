@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// matchGlob reports whether a slash-separated relative path matches a glob pattern.
+// Besides the path.Match syntax, a "**" segment matches any number of directories,
+// so "migrations/**" matches the migrations directory and everything inside it.
+// A pattern without a slash, such as "*.png", matches the base name at any depth;
+// a leading slash anchors it to the root instead.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	name = strings.Trim(filepath.ToSlash(name), "/")
+	if !anchored {
+		ok, err := path.Match(pattern, path.Base(name))
+		return err == nil && ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchAnyGlob reports whether the path matches at least one of the patterns.
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// evalCondition renders a template expression and reports whether the result is truthy.
+// The expression may be written with or without delimiters, e.g. ".UseDocker" or
+// "{{ ne .Database \"none\" }}", using the template's delimiters if it changes them.
+func evalCondition(expr string, data TemplateData) (bool, error) {
+	if !strings.Contains(expr, data.leftDelim()) {
+		expr = data.leftDelim() + " " + expr + " " + data.rightDelim()
+	}
+	result, err := renderString("condition", expr, data)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(result)) {
+	case "", "false", "0", "no":
+		return false, nil
+	}
+	return true, nil
+}
+
+// includeFile evaluates the `files:` rules of a template for a relative path.
+// A path is included only if every rule whose pattern matches it evaluates to true.
+func includeFile(rules map[string]string, relativePath string, data TemplateData) (bool, error) {
+	for pattern, expr := range rules {
+		if !matchGlob(pattern, relativePath) {
+			continue
+		}
+		ok, err := evalCondition(expr, data)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate condition for %s: %w", pattern, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseMode parses an octal permission string such as "0755" or "755".
+func parseMode(s string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q", s)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// checkModes validates the `modes:` section of a template config.
+func checkModes(modes map[string]string) error {
+	for pattern, value := range modes {
+		if _, err := parseMode(value); err != nil {
+			return fmt.Errorf("mode for %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// fileMode returns the permissions for a generated file: the mode of the most
+// specific (longest) matching pattern in modes, or the source file's own mode.
+func fileMode(modes map[string]string, relativePath string, mode fs.FileMode) fs.FileMode {
+	best := ""
+	for pattern := range modes {
+		if matchGlob(pattern, relativePath) && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return mode.Perm()
+	}
+	override, err := parseMode(modes[best])
+	if err != nil {
+		return mode.Perm()
+	}
+	return override
+}
+
+// checkDelimiters validates the global and per-pattern delimiter pairs.
+func checkDelimiters(delims []string, fileDelims map[string][]string) error {
+	check := func(pair []string) error {
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			return fmt.Errorf("expected two non-empty delimiters, got %q", pair)
+		}
+		return nil
+	}
+
+	if delims != nil {
+		if err := check(delims); err != nil {
+			return err
+		}
+	}
+	for pattern, pair := range fileDelims {
+		if err := check(pair); err != nil {
+			return fmt.Errorf("delimiters for %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// fileDelimiters returns the delimiters for a file: those of the most specific
+// (longest) matching pattern in file_delimiters, or the template-wide ones.
+func fileDelimiters(config TemplateConfig, relativePath string) []string {
+	best := ""
+	for pattern := range config.FileDelimiters {
+		if matchGlob(pattern, relativePath) && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return config.Delimiters
+	}
+	return config.FileDelimiters[best]
+}
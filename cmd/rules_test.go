@@ -0,0 +1,93 @@
+package cmd
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		// Patterns without a slash match the base name at any depth.
+		{"*.png", "logo.png", true},
+		{"*.png", "assets/img/logo.png", true},
+		{"*.png", "logo.png.bak", false},
+		{"Dockerfile", "deploy/Dockerfile", true},
+
+		// Patterns with a slash are anchored to the root.
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "site/docs/intro.md", false},
+		{"docs/*.md", "docs/guide/intro.md", false},
+
+		// A leading slash anchors a pattern without another slash.
+		{"/README.md", "README.md", true},
+		{"/README.md", "docs/README.md", false},
+
+		// "**" at the start matches any number of leading directories.
+		{"**/testdata", "testdata", true},
+		{"**/testdata", "a/b/testdata", true},
+		{"**/testdata", "a/b/testdata/x", false},
+
+		// "**" in the middle matches zero or more directories.
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+
+		// "**" at the end matches the directory and everything inside it.
+		{"migrations/**", "migrations", true},
+		{"migrations/**", "migrations/001.sql", true},
+		{"migrations/**", "migrations/a/b.sql", true},
+		{"migrations/**", "other/001.sql", false},
+
+		// A trailing slash is ignored by the glob itself.
+		{"build/", "build", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAnyGlob(t *testing.T) {
+	patterns := []string{"*.png", "vendor/**"}
+	for name, want := range map[string]bool{
+		"a/logo.png":      true,
+		"vendor/x/y.go":   true,
+		"cmd/main.go":     false,
+		"src/vendor/x.go": false,
+	} {
+		if got := matchAnyGlob(patterns, name); got != want {
+			t.Errorf("matchAnyGlob(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestEvalCondition(t *testing.T) {
+	data := TemplateData{
+		ProjectName: "demo",
+		Variables:   map[string]interface{}{"UseDocker": true, "Database": "none", "Replicas": 0},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{".UseDocker", true},
+		{"not .UseDocker", false},
+		{`{{ ne .Database "none" }}`, false},
+		{`eq .Database "none"`, true},
+		{".Replicas", false},
+		{".ProjectName", true},
+	}
+	for _, tt := range tests {
+		got, err := evalCondition(tt.expr, data)
+		if err != nil || got != tt.want {
+			t.Errorf("evalCondition(%q) = %v, %v, want %v", tt.expr, got, err, tt.want)
+		}
+	}
+
+	// A misspelled variable is an error rather than false.
+	if got, err := evalCondition(".UseDockr", data); err == nil {
+		t.Errorf("evalCondition(%q) = %v, want an error", ".UseDockr", got)
+	}
+}
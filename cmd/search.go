@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search [term]",
+	Short: "Searches the template registry.",
+	Long: `Lists the templates in the configured registry indexes whose ID, name,
+description or tags contain the term, or every template without a term.
+
+Registry indexes are YAML or JSON files served over HTTP or read from a local
+path or file:// URL. They are listed under 'registries' in registries.yaml,
+next to the templates directory, or given with --registry. Install a template
+found here with 'forma add <id>'.`,
+	Example: `  forma search
+  forma search go
+  forma search api --registry https://starters.example.com/index.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		templates, err := loadRegistries()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		templatesPath, err := getTemplatesPath()
+		if err != nil {
+			fmt.Printf("Error getting templates path: %v\n", err)
+			os.Exit(1)
+		}
+
+		found := 0
+		for _, template := range templates {
+			if len(args) == 1 && !template.matches(args[0]) {
+				continue
+			}
+			name := template.Name
+			if name == "" {
+				name = template.ID
+			}
+			if _, err := os.Lstat(filepath.Join(templatesPath, template.ID)); err == nil {
+				name += " (installed)"
+			}
+
+			fmt.Printf("  %s\n", name)
+			fmt.Printf("    └─ ID: %s\n", template.ID)
+			desc := strings.SplitN(template.Description, "\n", 2)[0]
+			if len(desc) > 100 {
+				desc = desc[:97] + "..."
+			}
+			fmt.Printf("    └─ Description: %s\n", desc)
+			if len(template.Tags) > 0 {
+				fmt.Printf("    └─ Tags: %s\n", strings.Join(template.Tags, ", "))
+			}
+			source := template.URL
+			if template.Subdir != "" {
+				source += " (" + template.Subdir + ")"
+			}
+			fmt.Printf("    └─ Source: %s\n\n", source)
+			found++
+		}
+
+		if found == 0 {
+			if len(args) == 1 {
+				fmt.Printf("No templates matching '%s' found.\n", args[0])
+			} else {
+				fmt.Println("The registry lists no templates.")
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringVar(&registryURL, "registry", "", "Registry index to search instead of the configured ones")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// projectTransaction generates a project in a staging directory next to its
+// final location, so that a failure never leaves a half-populated project
+// behind or loses the directory it was meant to replace.
+type projectTransaction struct {
+	projectPath string
+	stagingPath string
+	backupPath  string
+	committed   bool
+}
+
+// beginProject creates the staging directory for a project.
+func beginProject(projectPath string) (*projectTransaction, error) {
+	parent, base := filepath.Dir(projectPath), filepath.Base(projectPath)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	// Stage in the same parent directory so the final rename is atomic.
+	stagingPath, err := os.MkdirTemp(parent, "."+base+".forma-staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.Chmod(stagingPath, 0755); err != nil {
+		os.RemoveAll(stagingPath)
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return &projectTransaction{projectPath: projectPath, stagingPath: stagingPath}, nil
+}
+
+// commit moves the staged project into place. An existing project directory
+// is moved aside as a backup first, so it can be restored by rollback.
+func (t *projectTransaction) commit() error {
+	if _, err := os.Lstat(t.projectPath); err == nil {
+		t.backupPath = siblingPath(t.projectPath, "forma-backup")
+		if err := os.Rename(t.projectPath, t.backupPath); err != nil {
+			t.backupPath = ""
+			return fmt.Errorf("failed to move existing project directory aside: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check project directory: %w", err)
+	}
+
+	if err := os.Rename(t.stagingPath, t.projectPath); err != nil {
+		if t.backupPath != "" {
+			os.Rename(t.backupPath, t.projectPath)
+			t.backupPath = ""
+		}
+		return fmt.Errorf("failed to move project into place: %w", err)
+	}
+	t.committed = true
+	return nil
+}
+
+// rollback undoes a failed generation: the new output is removed, or kept
+// for debugging when keep is true, and any previous directory is restored.
+func (t *projectTransaction) rollback(keep bool) {
+	failedPath := t.stagingPath
+	if t.committed {
+		failedPath = t.projectPath
+	}
+
+	if keep {
+		if t.committed {
+			keptPath := siblingPath(t.projectPath, "forma-failed")
+			if err := os.Rename(t.projectPath, keptPath); err != nil {
+				fmt.Printf("Warning: could not move failed project aside: %v\n", err)
+				return
+			}
+			failedPath = keptPath
+		}
+		fmt.Printf("Partial output kept in '%s'.\n", failedPath)
+	} else if err := os.RemoveAll(failedPath); err != nil {
+		fmt.Printf("Warning: could not remove partial output in '%s': %v\n", failedPath, err)
+	}
+
+	if t.backupPath != "" {
+		if err := os.Rename(t.backupPath, t.projectPath); err != nil {
+			fmt.Printf("Warning: could not restore previous project directory, it is kept in '%s': %v\n", t.backupPath, err)
+			return
+		}
+		fmt.Printf("Restored previous contents of '%s'.\n", t.projectPath)
+	}
+}
+
+// finish removes the backup of a replaced project once generation succeeded.
+func (t *projectTransaction) finish() {
+	if t.backupPath == "" {
+		return
+	}
+	if err := os.RemoveAll(t.backupPath); err != nil {
+		fmt.Printf("Warning: could not remove backup '%s': %v\n", t.backupPath, err)
+	}
+}
+
+// siblingPath returns a hidden, timestamped path next to path with the given suffix.
+func siblingPath(path, suffix string) string {
+	name := fmt.Sprintf(".%s.%s-%s", filepath.Base(path), suffix, time.Now().Format("20060102-150405"))
+	return filepath.Join(filepath.Dir(path), name)
+}
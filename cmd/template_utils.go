@@ -1,50 +1,207 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"io/fs"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"gopkg.in/yaml.v3"
 )
 
-// processAndCopyFile reads a source file, processes it as a Go template,
-// and writes the output to the destination file.
-func processAndCopyFile(src, dst string, data TemplateData) error {
+// binarySniffLen is how many leading bytes are inspected to detect binary files.
+const binarySniffLen = 8000
+
+// isBinary reports whether content looks like binary data, using the same
+// heuristic as git: a NUL byte within the first few kilobytes.
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLen {
+		content = content[:binarySniffLen]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// plannedFile is a directory, file or symlink a template generates,
+// with its rendered path relative to the project root.
+type plannedFile struct {
+	path    string
+	isDir   bool
+	symlink bool
+	// content is the rendered file content, or the rendered target of a symlink.
+	content []byte
+	mode    fs.FileMode
+}
+
+// processFile reads a source file and processes it as a Go template.
+// Binary files, and any file when render is false, are returned verbatim.
+func processFile(src string, render bool, data TemplateData) ([]byte, error) {
 	// Read the source file content
 	content, err := os.ReadFile(src)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", src, err)
+		return nil, fmt.Errorf("failed to read file %s: %w", src, err)
+	}
+
+	if !render || isBinary(content) {
+		return content, nil
 	}
 
 	// Create a new template and parse the file content
-	tmpl, err := template.New(filepath.Base(src)).Parse(string(content))
+	tmpl, err := newTemplate(filepath.Base(src), data).Parse(string(content))
 	if err != nil {
-		return fmt.Errorf("failed to parse template %s: %w", src, err)
+		return nil, fmt.Errorf("failed to parse template %s: %w", src, err)
+	}
+
+	// Execute the template into memory
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data.context()); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
 	}
+	return out.Bytes(), nil
+}
 
-	// Create the destination file
-	destFile, err := os.Create(dst)
+// processSymlink reads the target of a symlink in the template and renders it.
+func processSymlink(src string, data TemplateData) ([]byte, error) {
+	target, err := os.Readlink(src)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file %s: %w", dst, err)
+		return nil, fmt.Errorf("failed to read symlink %s: %w", src, err)
 	}
-	defer destFile.Close()
+	rendered, err := renderString(filepath.Base(src), target, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render symlink target %s: %w", src, err)
+	}
+	return []byte(rendered), nil
+}
 
-	// Execute the template, writing the output to the destination file
-	err = tmpl.Execute(destFile, data)
+// writePlannedFile creates a planned directory, file or symlink below projectPath.
+func writePlannedFile(projectPath string, file plannedFile) error {
+	destPath := filepath.Join(projectPath, file.path)
+
+	if file.isDir {
+		// Use standard permissions (0777) to avoid permission issues.
+		return os.MkdirAll(destPath, 0777)
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0777); err != nil {
+		return err
+	}
+	if file.symlink {
+		if err := os.Symlink(string(file.content), destPath); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", destPath, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(destPath, file.content, file.mode); err != nil {
+		return fmt.Errorf("failed to create destination file %s: %w", destPath, err)
+	}
+	// Apply the exact mode, which os.WriteFile filters through the umask.
+	return os.Chmod(destPath, file.mode)
+}
+
+// loadTemplateConfig reads and validates the template.yaml file of a template.
+func loadTemplateConfig(templatePath string) (TemplateConfig, error) {
+	var config TemplateConfig
+
+	yamlFile, err := os.ReadFile(filepath.Join(templatePath, "template.yaml"))
 	if err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+		return config, fmt.Errorf("failed to read template config: %w", err)
+	}
+	if err := yaml.Unmarshal(yamlFile, &config); err != nil {
+		return config, fmt.Errorf("failed to parse template config: %w", err)
 	}
+	if err := checkVariables(config.Variables); err != nil {
+		return config, fmt.Errorf("invalid template variables: %w", err)
+	}
+	if err := checkModes(config.Modes); err != nil {
+		return config, fmt.Errorf("invalid template modes: %w", err)
+	}
+	if err := checkDelimiters(config.Delimiters, config.FileDelimiters); err != nil {
+		return config, fmt.Errorf("invalid template delimiters: %w", err)
+	}
+	if err := checkHooks(config.Hooks); err != nil {
+		return config, fmt.Errorf("invalid template hooks: %w", err)
+	}
+	return config, nil
+}
 
-	return nil
+// newTemplate creates a template with the built-in function library,
+// using the delimiters configured for the data. Referring to an unknown
+// name is an error, so a misspelled variable is not rendered as "<no value>".
+func newTemplate(name string, data TemplateData) *template.Template {
+	return template.New(name).Delims(data.leftDelim(), data.rightDelim()).Funcs(templateFuncs).Option("missingkey=error")
+}
+
+// renderString processes a string as a Go template against the template data.
+func renderString(name, text string, data TemplateData) (string, error) {
+	tmpl, err := newTemplate(name, data).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data.context()); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// renderPath renders each segment of a relative template path.
+// It reports false if any segment renders to an empty string,
+// meaning the file or directory should not be generated.
+func renderPath(relativePath string, data TemplateData) (string, bool, error) {
+	segments := strings.Split(filepath.ToSlash(relativePath), "/")
+	for i, segment := range segments {
+		rendered, err := renderString(segment, segment, data)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to render path %s: %w", relativePath, err)
+		}
+		rendered = strings.TrimSpace(rendered)
+		if rendered == "" {
+			return "", false, nil
+		}
+		if rendered == ".." || strings.ContainsAny(rendered, `/\`) {
+			return "", false, fmt.Errorf("path %s renders to invalid name %q", relativePath, rendered)
+		}
+		segments[i] = rendered
+	}
+	return filepath.Join(segments...), true, nil
 }
 
-// copyTemplate walks through a template directory and copies its structure and files.
-func copyTemplate(templatePath, projectPath string, data TemplateData) error {
+// copyTemplate walks through a template directory and copies its structure and
+// files. It returns the files it wrote.
+func copyTemplate(templatePath, projectPath string, config TemplateConfig, data TemplateData) ([]plannedFile, error) {
+	files, err := renderTemplate(templatePath, config, data)
+	if err != nil {
+		return nil, err
+	}
+
 	// Make sure the destination project directory exists.
 	// os.MkdirAll is safe to call even if the directory already exists.
 	if err := os.MkdirAll(projectPath, 0755); err != nil {
-		return fmt.Errorf("failed to create project directory: %w", err)
+		return nil, fmt.Errorf("failed to create project directory: %w", err)
+	}
+	for _, file := range files {
+		if err := writePlannedFile(projectPath, file); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// renderTemplate walks through a template directory and renders its structure
+// and files in memory, without writing anything to disk.
+func renderTemplate(templatePath string, config TemplateConfig, data TemplateData) ([]plannedFile, error) {
+	var files []plannedFile
+
+	// Templates added with `forma add --link` are symlinks to the directory being developed.
+	if resolved, err := filepath.EvalSymlinks(templatePath); err == nil {
+		templatePath = resolved
+	}
+
+	ignore, err := loadIgnore(templatePath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Walk the template directory.
@@ -59,25 +216,83 @@ func copyTemplate(templatePath, projectPath string, data TemplateData) error {
 			return err
 		}
 
-		// Create the full destination path.
-		destPath := filepath.Join(projectPath, relativePath)
-
 		// Skip the template.yaml file itself.
 		if d.Name() == "template.yaml" {
 			return nil
 		}
 
+		// Skip paths excluded by .formaignore, such as the template's .git directory.
+		if relativePath != "." && ignore.ignored(relativePath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Apply the conditional inclusion rules from template.yaml.
+		if relativePath != "." {
+			include, err := includeFile(config.Files, relativePath, data)
+			if err != nil {
+				return err
+			}
+			if !include {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		// Render templated names, e.g. cmd/{{ .ProjectName }}/main.go.
+		renderedPath, ok, err := renderPath(relativePath, data)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// A segment rendered to empty: skip this entry and everything below it.
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if renderedPath == "." {
+			return nil // The project root itself.
+		}
+		file := plannedFile{path: renderedPath}
+
 		if d.IsDir() {
 			// It's a directory, so create it in the destination.
-			// Use standard permissions (0777) to avoid permission issues.
-			return os.MkdirAll(destPath, 0777)
+			file.isDir = true
+		} else if d.Type()&fs.ModeSymlink != 0 {
+			// It's a symlink, so recreate it rather than copying what it points to.
+			file.symlink = true
+			if file.content, err = processSymlink(path, data); err != nil {
+				return err
+			}
 		} else {
-			// It's a file, so copy it.
-			return processAndCopyFile(path, destPath, data)
+			// It's a file, so copy it, rendering it unless listed in copy_without_render.
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file.mode = fileMode(config.Modes, relativePath, info.Mode())
+			render := !matchAnyGlob(config.CopyWithoutRender, relativePath)
+			fileData := data
+			fileData.delims = fileDelimiters(config, relativePath)
+			if file.content, err = processFile(path, render, fileData); err != nil {
+				return err
+			}
 		}
+
+		files = append(files, file)
+		return nil
 	}
 
-	return filepath.WalkDir(templatePath, walkFunc)
+	if err := filepath.WalkDir(templatePath, walkFunc); err != nil {
+		return nil, err
+	}
+	return files, nil
 }
 
 // getAvailableTemplates scans the templates directory and returns a slice of template names.
@@ -94,7 +309,7 @@ func getAvailableTemplates() ([]string, error) {
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || entry.Type()&fs.ModeSymlink != 0 {
 			// Check if a template.yaml exists before adding it to the list
 			configPath := filepath.Join(templatesPath, entry.Name(), "template.yaml")
 			if _, err := os.Stat(configPath); err == nil {
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderStringMissingKey(t *testing.T) {
+	data := TemplateData{
+		ProjectName: "demo",
+		Variables:   map[string]interface{}{"UseDocker": true},
+	}
+
+	got, err := renderString("test", "{{ .ProjectName }} {{ .UseDocker }}", data)
+	if err != nil || got != "demo true" {
+		t.Fatalf("renderString() = %q, %v, want %q", got, err, "demo true")
+	}
+
+	for _, text := range []string{"{{ .Typo }}", "{{ if .UseDockr }}x{{ end }}", "{{ .File }}"} {
+		if got, err := renderString("test", text, data); err == nil || !strings.Contains(err.Error(), "map has no entry") {
+			t.Errorf("renderString(%q) = %q, %v, want a missing key error", text, got, err)
+		}
+	}
+}
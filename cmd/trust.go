@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// trustFileName is the file next to the templates directory that records
+// which template hooks have been approved.
+const trustFileName = "trust.yaml"
+
+var revokeTrust bool
+
+// trustStore is the content of the trust file.
+type trustStore struct {
+	// Sources lists URL prefixes whose templates are trusted when added.
+	Sources []string `yaml:"sources,omitempty"`
+	// Templates maps template names to their approved hooks.
+	Templates map[string]trustEntry `yaml:"templates,omitempty"`
+}
+
+// trustEntry records the hash of a template's hooks at the time they were approved.
+type trustEntry struct {
+	Hooks    string `yaml:"hooks"`
+	Approved string `yaml:"approved"`
+}
+
+// trustStorePath returns the location of the trust file.
+func trustStorePath() (string, error) {
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(templatesPath), trustFileName), nil
+}
+
+// loadTrustStore reads the trust file. A missing file is an empty store.
+func loadTrustStore() (trustStore, error) {
+	store := trustStore{Templates: make(map[string]trustEntry)}
+	storePath, err := trustStorePath()
+	if err != nil {
+		return store, err
+	}
+	content, err := os.ReadFile(storePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("failed to read %s: %w", trustFileName, err)
+	}
+	if err := yaml.Unmarshal(content, &store); err != nil {
+		return store, fmt.Errorf("failed to parse %s: %w", trustFileName, err)
+	}
+	if store.Templates == nil {
+		store.Templates = make(map[string]trustEntry)
+	}
+	return store, nil
+}
+
+// save writes the trust file.
+func (s trustStore) save() error {
+	storePath, err := trustStorePath()
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", trustFileName, err)
+	}
+	if err := os.WriteFile(storePath, content.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", trustFileName, err)
+	}
+	return nil
+}
+
+// approve records the current hooks of a template as trusted.
+func (s trustStore) approve(templateName string, hooks HooksConfig) error {
+	hash, err := hooksHash(hooks)
+	if err != nil {
+		return err
+	}
+	s.Templates[templateName] = trustEntry{Hooks: hash, Approved: time.Now().UTC().Format(time.RFC3339)}
+	return nil
+}
+
+// trustedSource reports whether a template URL is one of the trusted sources
+// or lies below one of them. Sources only match whole path segments, so
+// https://git.example.com/acme does not trust https://git.example.com/acme-evil.
+func (s trustStore) trustedSource(source string) bool {
+	location, ok := sourceLocation(source)
+	if !ok {
+		return false
+	}
+	for _, prefix := range s.Sources {
+		trusted, ok := sourceLocation(prefix)
+		if !ok {
+			continue
+		}
+		trusted = strings.TrimSuffix(trusted, "/")
+		if location == trusted || strings.HasPrefix(location, trusted+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLocation returns a template source in a canonical form for comparing
+// it with trusted sources: a URL with a lower-case scheme and host and a
+// cleaned path. SCP-like SSH addresses become ssh:// URLs and local paths
+// become file:// URLs. Sources with ".." segments, a query or a fragment are
+// rejected.
+func sourceLocation(source string) (string, bool) {
+	if source == "" {
+		return "", false
+	}
+	if !strings.Contains(source, "://") {
+		if user, rest, found := strings.Cut(source, "@"); found && strings.Contains(rest, ":") && !strings.ContainsAny(user, `/\`) {
+			host, repoPath, _ := strings.Cut(rest, ":")
+			source = "ssh://" + user + "@" + host + "/" + strings.TrimPrefix(repoPath, "/")
+		} else {
+			if hasDotDot(filepath.ToSlash(source)) {
+				return "", false
+			}
+			abs, err := filepath.Abs(source)
+			if err != nil {
+				return "", false
+			}
+			source = "file://" + filepath.ToSlash(abs)
+		}
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Opaque != "" || u.RawQuery != "" || u.Fragment != "" || hasDotDot(u.Path) {
+		return "", false
+	}
+	location := strings.ToLower(u.Scheme) + "://"
+	if u.User != nil {
+		location += u.User.Username() + "@"
+	}
+	location += strings.ToLower(u.Host)
+	if u.Path != "" {
+		location += path.Clean("/" + u.Path)
+	}
+	return location, true
+}
+
+// hasDotDot reports whether a slash-separated path has a ".." segment.
+func hasDotDot(p string) bool {
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHooks reports whether any stage has a hook.
+func hasHooks(hooks HooksConfig) bool {
+	return len(hooks.PrePrompt)+len(hooks.PreCreate)+len(hooks.PostRender)+len(hooks.PostCreate) > 0
+}
+
+// hooksHash returns a digest of every hook of a template, so any change to
+// a command or its settings is detected.
+func hooksHash(hooks HooksConfig) (string, error) {
+	content, err := yaml.Marshal(hooks)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode hooks: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Trust states of a template's hooks.
+const (
+	hooksTrusted   = "trusted"
+	hooksUntrusted = "untrusted"
+	hooksChanged   = "changed"
+)
+
+// hooksTrust returns whether the hooks of a template may run. Hooks are
+// trusted when they match the approved hash, or when the template is the
+// first-run copy of a bundled template and its hooks are unchanged.
+func hooksTrust(templateName string, hooks HooksConfig) (string, error) {
+	if !hasHooks(hooks) {
+		return hooksTrusted, nil
+	}
+	hash, err := hooksHash(hooks)
+	if err != nil {
+		return "", err
+	}
+
+	store, err := loadTrustStore()
+	if err != nil {
+		return "", err
+	}
+	entry, approved := store.Templates[templateName]
+	if approved && entry.Hooks == hash {
+		return hooksTrusted, nil
+	}
+
+	// Bundled templates are trusted as shipped, by this or an earlier version
+	// of forma, unless the installed template was added with `forma add`.
+	if bundledCopy(templateName) && bundledHooks(templateName, hash) {
+		return hooksTrusted, nil
+	}
+
+	if approved {
+		return hooksChanged, nil
+	}
+	return hooksUntrusted, nil
+}
+
+// shippedHooks lists the hashes of hooks that earlier forma versions bundled
+// with their templates. The templates directory is only filled on first run,
+// so existing copies keep these hooks after forma is upgraded.
+var shippedHooks = map[string][]string{
+	"go-api": {
+		"sha256:a73d1263d0391bc50497f707932562f48cddf1042cf1e2612940f18bd8164df4",
+		"sha256:136d8d81da9237ccd04c5f43b05a1b85a2cf0774ef7a45558601daab0c99caa8",
+		"sha256:c687df1c8ca39b4b49a4b321cd2c80298fb40503a97f67f889178eafe3ffb2ee",
+		"sha256:6fb39bc0188de6e2e3b689f1f0444ce56506b91a70b73cce9463b9a1745e03b8",
+	},
+	"go-gin-api": {
+		"sha256:b038e315c17cd00be0db47293f37deb553bca88543a53b19b43b62f4be08e8ce",
+		"sha256:8be3d21f7524f64da1ef3c26707a5fb81d3985211b1527fa975f1a371ba30a70",
+		"sha256:49c5133c5b011858fc2bdce7b5ceea8b5de04b3d31cb1d751099edcf539aedf5",
+	},
+	"pygame": {
+		"sha256:667a39f4f95da68b51a9f2b4163b34f6774359065395eb68b643f50bf300b474",
+		"sha256:399f2b446785041f74b6988c967c8d45059b2274541af476d0b8dd1d2ab42fbd",
+	},
+	"rag-agent": {
+		"sha256:2ed6b1819cf33ab1ce1b07665b2226b0db6443fa1d8d25d2b28ca2d2b6c559a0",
+		"sha256:a5d53e8060f25f44c727e0f447a1958babc9979a68a3dc840600a223214e34e4",
+	},
+}
+
+// bundledCopy reports whether an installed template is the copy of a bundled
+// template made on first run, rather than one added with `forma add`.
+func bundledCopy(templateName string) bool {
+	if _, err := fs.Stat(embeddedTemplates, path.Join("templates", templateName)); err != nil {
+		return false
+	}
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		return false
+	}
+	templatePath := filepath.Join(templatesPath, templateName)
+	if info, err := os.Lstat(templatePath); err != nil || !info.IsDir() {
+		return false
+	}
+	_, added, err := readTemplateOrigin(templatePath)
+	return err == nil && !added
+}
+
+// bundledHooks reports whether hash belongs to the hooks of the bundled
+// template, as shipped now or by an earlier version.
+func bundledHooks(templateName, hash string) bool {
+	if content, err := fs.ReadFile(embeddedTemplates, path.Join("templates", templateName, "template.yaml")); err == nil {
+		var bundled TemplateConfig
+		if yaml.Unmarshal(content, &bundled) == nil {
+			if bundledHash, err := hooksHash(bundled.Hooks); err == nil && bundledHash == hash {
+				return true
+			}
+		}
+	}
+	for _, shipped := range shippedHooks[templateName] {
+		if shipped == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// disableUntrustedHooks removes the hooks of a template unless they are
+// trusted, and explains how to enable them.
+func disableUntrustedHooks(templateName string, config *TemplateConfig) error {
+	trust, err := hooksTrust(templateName, config.Hooks)
+	if err != nil {
+		return err
+	}
+	switch trust {
+	case hooksUntrusted:
+		fmt.Printf("⚠️  Hooks of template '%s' are not trusted and will not run.\n", templateName)
+	case hooksChanged:
+		fmt.Printf("⚠️  Hooks of template '%s' changed since they were approved and will not run.\n", templateName)
+	default:
+		return nil
+	}
+	fmt.Printf("   Review them with 'forma trust %s' to enable them.\n", templateName)
+	config.Hooks = HooksConfig{}
+	return nil
+}
+
+// printTemplateHooks lists the hooks of every stage as written in template.yaml.
+func printTemplateHooks(hooks HooksConfig) {
+	stages := []struct {
+		name  string
+		hooks []Hook
+	}{
+		{stagePrePrompt, hooks.PrePrompt},
+		{stagePreCreate, hooks.PreCreate},
+		{stagePostRender, hooks.PostRender},
+		{stagePostCreate, hooks.PostCreate},
+	}
+	for _, stage := range stages {
+		if len(stage.hooks) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", stage.name)
+		var listed []renderedHook
+		for _, hook := range stage.hooks {
+			r := renderedHook{Hook: hook}
+			if hook.When != "" {
+				r.Run += "  [when " + hook.When + "]"
+			}
+			names := make([]string, 0, len(hook.Env))
+			for name := range hook.Env {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				r.env = append(r.env, name+"="+hook.Env[name])
+			}
+			listed = append(listed, r)
+		}
+		printHooks(listed)
+	}
+}
+
+// trustCmd represents the trust command
+var trustCmd = &cobra.Command{
+	Use:   "trust <template_name>",
+	Short: "Approve the hooks of a template.",
+	Long: `Shows the hooks of a template and, once approved, allows them to run.
+
+Hooks of templates added with 'forma add' do not run until they are approved.
+The approval is tied to the exact hooks: if they change, for example after the
+template is updated, they are disabled again until they are approved anew.
+
+Templates from trusted sources are approved when they are added. Trusted
+sources are URLs listed under 'sources' in trust.yaml, next to the templates
+directory; templates at or below one of them are trusted.`,
+	Example: `  forma trust my-template
+  forma trust my-template --revoke`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		templateName := args[0]
+
+		templatesPath, err := getTemplatesPath()
+		if err != nil {
+			fmt.Printf("Error getting templates path: %v\n", err)
+			os.Exit(1)
+		}
+		store, err := loadTrustStore()
+		if err != nil {
+			fmt.Printf("Error loading trusted templates: %v\n", err)
+			os.Exit(1)
+		}
+
+		if revokeTrust {
+			delete(store.Templates, templateName)
+			if err := store.save(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Hooks of template '%s' will no longer run.\n", templateName)
+			return
+		}
+
+		config, err := loadTemplateConfig(filepath.Join(templatesPath, templateName))
+		if err != nil {
+			fmt.Printf("Error loading template: %v\n", err)
+			os.Exit(1)
+		}
+		if !hasHooks(config.Hooks) {
+			fmt.Printf("Template '%s' has no hooks.\n", templateName)
+			return
+		}
+
+		fmt.Printf("Template '%s' runs the following hooks with 'sh':\n\n", templateName)
+		printTemplateHooks(config.Hooks)
+		fmt.Println()
+
+		approve, err := confirm("Do you trust these hooks and want to allow them to run?")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !approve {
+			fmt.Println("Aborted.")
+			return
+		}
+
+		if err := store.approve(templateName, config.Hooks); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := store.save(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Hooks of template '%s' are now trusted.\n", templateName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+	trustCmd.Flags().BoolVar(&revokeTrust, "revoke", false, "Stop trusting the hooks of the template")
+	trustCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Approve without asking")
+}
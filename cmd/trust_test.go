@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestShippedHooks checks that the hooks of every template bundled by an
+// earlier forma version, kept in testdata/shipped, are still recognised. It
+// fails if a change to the hook format alters their hashes.
+func TestShippedHooks(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "shipped", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no shipped templates found: %v", err)
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		templateName := name[:strings.LastIndex(name, "-")]
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config TemplateConfig
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		hash, err := hooksHash(config.Hooks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bundledHooks(templateName, hash) {
+			t.Errorf("%s: hooks %s are not recognised as shipped with %s", name, hash, templateName)
+		}
+		if bundledHooks("other", hash) {
+			t.Errorf("%s: hooks are recognised for another template", name)
+		}
+	}
+}
+
+func TestTrustedSource(t *testing.T) {
+	store := trustStore{Sources: []string{
+		"https://git.example.com/acme",
+		"https://git.example.com/starters/",
+		"git@git.example.com:team",
+		"/srv/templates",
+	}}
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"https://git.example.com/acme", true},
+		{"https://git.example.com/acme/", true},
+		{"https://git.example.com/acme/api.git", true},
+		{"HTTPS://GIT.EXAMPLE.COM/acme/api.git", true},
+		{"https://git.example.com/starters", true},
+		{"https://git.example.com/starters/go.git", true},
+		{"https://git.example.com/starters/a/./b.git", true},
+		{"git@git.example.com:team/api.git", true},
+		{"ssh://git@git.example.com/team/api.git", true},
+		{"/srv/templates/go-api", true},
+		{"file:///srv/templates/go-api", true},
+
+		// Only whole path segments match.
+		{"https://git.example.com/acme-evil/x.git", false},
+		{"git@git.example.com:team-evil/api.git", false},
+		{"/srv/templates-evil/go-api", false},
+
+		// Paths leaving a trusted source are never trusted.
+		{"https://git.example.com/starters/../other/x.git", false},
+		{"https://git.example.com/starters/%2e%2e/other/x.git", false},
+		{"https://git.example.com/acme/../../x.git", false},
+		{"git@git.example.com:team/../other.git", false},
+		{"/srv/templates/../../etc", false},
+
+		{"https://git.example.com.evil.com/acme/x.git", false},
+		{"https://git.example.com/acme/x.git?ref=../../other", false},
+		{"http://git.example.com/acme/x.git", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := store.trustedSource(tt.source); got != tt.want {
+			t.Errorf("trustedSource(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
@@ -3,14 +3,28 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/lipgloss"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type (
-	step  int
+	step       int
+	promptKind int
+	// prompt is a single question asked once a template has been chosen.
+	// Built-in values (project name, author) have no variable attached.
+	prompt struct {
+		name     string
+		title    string
+		help     string
+		kind     promptKind
+		options  []string
+		variable *Variable
+	}
 	model struct {
 		step        step
 		templates   []string
@@ -18,20 +32,41 @@ type (
 		template    string
 		projectName string
 		author      string
+		flagAuthor  string
+		prompts     []prompt
+		index       int
+		answers     map[string]interface{}
+		selected    map[int]bool
 		textInput   textinput.Model
+		done        bool
+		// prePrompt is set when the TUI stopped to let the chosen
+		// template's pre-prompt hooks run; see resume.
+		prePrompt   bool
+		prePrompted map[string]bool
 		err         error
 		errorStyle  lipgloss.Style
+		helpStyle   lipgloss.Style
+		// preset holds values given by arguments, flags or an answers
+		// file; their questions are not asked.
+		preset map[string]interface{}
 	}
 )
 
 const (
 	stepChooseTemplate step = iota
-	stepEnterProjectName
-	stepEnterAuthorName
+	stepPrompts
 )
 
-// Initialize the model with available templates.
-func initialModel(flagAuthor string) model {
+const (
+	promptText promptKind = iota
+	promptToggle
+	promptSelect
+	promptMultiSelect
+)
+
+// Initialize the model with available templates. Values in preset, such as
+// those given with --set, are used as they are instead of being asked for.
+func initialModel(flagAuthor, projectName string, preset map[string]interface{}) model {
 	templates, err := getAvailableTemplates()
 	if err != nil {
 		fmt.Println("Error getting templates:", err)
@@ -44,13 +79,59 @@ func initialModel(flagAuthor string) model {
 	ti.Width = 20
 
 	return model{
-		step:      stepChooseTemplate,
-		templates: templates,
-		author:    flagAuthor,
-		textInput: ti,
-		err:       err,
-		errorStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
+		step:        stepChooseTemplate,
+		templates:   templates,
+		projectName: projectName,
+		author:      flagAuthor,
+		flagAuthor:  flagAuthor,
+		preset:      preset,
+		textInput:   ti,
+		prePrompted: make(map[string]bool),
+		err:         err,
+		errorStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
+		helpStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+	}
+}
+
+// buildPrompts returns the questions to ask for a template: the project name,
+// the author (unless given by flag), then every variable the template declares.
+// Questions whose answer is already known are left out.
+func buildPrompts(config TemplateConfig, flagAuthor, projectName string, preset map[string]interface{}) []prompt {
+	var prompts []prompt
+	if projectName == "" {
+		prompts = append(prompts, prompt{
+			name:  "ProjectName",
+			title: "What is the name of your project?",
+			kind:  promptText,
+		})
+	}
+	if flagAuthor == "" {
+		prompts = append(prompts, prompt{
+			name:  "Author",
+			title: "What is your GitHub username?",
+			kind:  promptText,
+		})
+	}
+
+	for i := range config.Variables {
+		v := &config.Variables[i]
+		if _, ok := preset[v.Name]; ok {
+			continue
+		}
+		p := prompt{name: v.Name, title: v.Name, help: v.Help, options: v.Choices, variable: v}
+		switch v.kind() {
+		case varTypeBool:
+			p.kind = promptToggle
+		case varTypeChoice:
+			p.kind = promptSelect
+		case varTypeMultiChoice:
+			p.kind = promptMultiSelect
+		default:
+			p.kind = promptText
+		}
+		prompts = append(prompts, p)
 	}
+	return prompts
 }
 
 func (m model) Init() tea.Cmd {
@@ -74,47 +155,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.cursor++
 				}
 			case "enter":
-				m.template = m.templates[m.cursor]
-				m.step = stepEnterProjectName // Move to next step
-				return m, nil
+				return m.chooseTemplate(m.templates[m.cursor])
 			}
 		}
 		return m, nil
 	}
-	// Handle the other steps which use text input
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
-		case "enter":
-			switch m.step {
-			case stepEnterProjectName:
-				m.projectName = m.textInput.Value()
-				if !isValidName(m.projectName) {
-					m.err = fmt.Errorf("invalid project name: %s\nNames should start with a letter or number, only contains letters, numbers, hyphens, or underscores", m.projectName)
-					return m, nil
-				}
-				m.err = nil // Reset error
-				m.textInput.Reset()
-				// If author was not provided by flag, ask for it. Otherwise, we're done.
-				if m.author == "" {
-					m.textInput.Placeholder = "YourGitHubUsername"
-					m.step = stepEnterAuthorName
-				} else {
-					return m, tea.Quit
-				}
-			case stepEnterAuthorName:
-				m.author = m.textInput.Value()
-				if !isValidName(m.author) {
-					m.err = fmt.Errorf("invalid author name: %s\nNames should start with a letter or number, only contains letters, numbers, hyphens, or underscores", m.author)
-					return m, nil
-				}
-				m.err = nil // Reset error
-				return m, tea.Quit
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		// Let the text input handle non-key messages such as cursor blinks.
+		var cmd tea.Cmd
+		m.textInput, cmd = m.textInput.Update(msg)
+		return m, cmd
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		return m.back(), nil
+	case "enter":
+		return m.submit()
+	}
+
+	p := m.prompts[m.index]
+	switch p.kind {
+	case promptToggle:
+		switch keyMsg.String() {
+		case "left", "right", "h", "l", "tab", " ":
+			m.cursor = 1 - m.cursor
+		case "y":
+			m.cursor = 0
+		case "n":
+			m.cursor = 1
+		}
+		return m, nil
+	case promptSelect, promptMultiSelect:
+		switch keyMsg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(p.options)-1 {
+				m.cursor++
+			}
+		case " ", "x":
+			if p.kind == promptMultiSelect {
+				m.selected[m.cursor] = !m.selected[m.cursor]
 			}
-			return m, nil
 		}
+		return m, nil
 	}
 
 	// Handle text input updates
@@ -123,6 +214,187 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// chooseTemplate loads the chosen template's config and builds its prompts.
+func (m model) chooseTemplate(name string) (tea.Model, tea.Cmd) {
+	templatesPath, err := getTemplatesPath()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	config, err := loadTemplateConfig(filepath.Join(templatesPath, name))
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.err = nil
+	m.template = name
+	// A dry run never runs hooks, so the questions follow right away.
+	if len(config.Hooks.PrePrompt) > 0 && !m.prePrompted[name] && !dryRun {
+		trust, err := hooksTrust(name, config.Hooks)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.prePrompt = trust == hooksTrusted
+	}
+	if m.prePrompt {
+		return m, tea.Quit
+	}
+	m.prompts = buildPrompts(config, m.flagAuthor, stringAnswer(m.preset, "ProjectName"), m.preset)
+	m.answers = make(map[string]interface{})
+	if len(m.prompts) == 0 {
+		m.done = true
+		return m, tea.Quit
+	}
+	m.step = stepPrompts // Move to next step
+	return m.enterPrompt(0), nil
+}
+
+// startAt skips the template list and asks the questions of a template given
+// on the command line, whose pre-prompt hooks have already run.
+func (m model) startAt(templateName string) model {
+	m.template = templateName
+	return m.resume()
+}
+
+// resume continues with the chosen template's questions once its pre-prompt
+// hooks have run.
+func (m model) resume() model {
+	m.prePrompt = false
+	m.prePrompted[m.template] = true
+	resumed, _ := m.chooseTemplate(m.template)
+	return resumed.(model)
+}
+
+// enterPrompt moves to the prompt at index i and restores its previous answer,
+// falling back to the variable's default.
+func (m model) enterPrompt(i int) model {
+	m.index = i
+	m.cursor = 0
+	m.selected = make(map[int]bool)
+	m.textInput.Reset()
+
+	p := m.prompts[i]
+	value, answered := m.answers[p.name]
+	if !answered && p.variable != nil {
+		value = p.variable.defaultValue()
+	}
+
+	switch p.kind {
+	case promptText:
+		switch p.name {
+		case "ProjectName":
+			m.textInput.Placeholder = "my-awesome-app"
+		case "Author":
+			m.textInput.Placeholder = "YourGitHubUsername"
+		default:
+			m.textInput.Placeholder = fmt.Sprint(value)
+		}
+		if answered {
+			m.textInput.SetValue(fmt.Sprint(value))
+		}
+	case promptToggle:
+		if value != true {
+			m.cursor = 1
+		}
+	case promptSelect:
+		for j, option := range p.options {
+			if option == value {
+				m.cursor = j
+			}
+		}
+	case promptMultiSelect:
+		values, _ := value.([]string)
+		for j, option := range p.options {
+			m.selected[j] = containsString(values, option)
+		}
+	}
+	return m
+}
+
+// back returns to the previous prompt, or to the template list from the first one.
+func (m model) back() model {
+	m.err = nil
+	if m.index > 0 {
+		return m.enterPrompt(m.index - 1)
+	}
+	m.step = stepChooseTemplate
+	m.cursor = 0
+	for i, tpl := range m.templates {
+		if tpl == m.template {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+// submit validates the answer to the current prompt and moves to the next one.
+func (m model) submit() (tea.Model, tea.Cmd) {
+	p := m.prompts[m.index]
+
+	var value interface{}
+	var err error
+	switch p.kind {
+	case promptText:
+		input := m.textInput.Value()
+		switch {
+		case p.name == "ProjectName":
+			value = input
+			if !isValidName(input) {
+				err = fmt.Errorf("invalid project name: %s\nNames should start with a letter or number, only contains letters, numbers, hyphens, or underscores", input)
+			}
+		case p.name == "Author":
+			value = input
+			if !isValidName(input) {
+				err = fmt.Errorf("invalid author name: %s\nNames should start with a letter or number, only contains letters, numbers, hyphens, or underscores", input)
+			}
+		case input == "":
+			// An empty answer accepts the default shown as placeholder.
+			value = p.variable.defaultValue()
+			err = p.variable.validate(value)
+		default:
+			value, err = p.variable.parse(input)
+		}
+	case promptToggle:
+		value = m.cursor == 0
+	case promptSelect:
+		value = p.options[m.cursor]
+	case promptMultiSelect:
+		var items []string
+		for j, option := range p.options {
+			if m.selected[j] {
+				items = append(items, option)
+			}
+		}
+		value, err = p.variable.coerce(items)
+	}
+
+	if err != nil {
+		if p.variable != nil {
+			err = fmt.Errorf("invalid value for %s: %w", p.name, err)
+		}
+		m.err = err
+		return m, nil
+	}
+	m.err = nil // Reset error
+
+	// Built-in answers are kept in answers too, so going back restores them.
+	m.answers[p.name] = value
+	switch p.name {
+	case "ProjectName":
+		m.projectName = value.(string)
+	case "Author":
+		m.author = value.(string)
+	}
+
+	if m.index == len(m.prompts)-1 {
+		m.done = true
+		return m, tea.Quit
+	}
+	return m.enterPrompt(m.index + 1), nil
+}
+
 func isValidName(name string) bool {
     if name == "" {
         return false
@@ -146,12 +418,10 @@ func (m model) View() string {
 			}
 			s += fmt.Sprintf("%s %s\n", cursor, tpl)
 		}
-	case stepEnterProjectName:
-		s = fmt.Sprintf("What is the name of your project?\n\n%s\n\n(press enter to confirm)", m.textInput.View())
-	case stepEnterAuthorName:
-		s = fmt.Sprintf("What is your GitHub username?\n\n%s\n\n(press enter to confirm)", m.textInput.View())
+	case stepPrompts:
+		s = m.viewPrompt()
 	}
-	
+
 	if m.err != nil {
 		s += fmt.Sprintf("\n\n%s", m.errorStyle.Render(m.err.Error()))
 	}
@@ -159,3 +429,55 @@ func (m model) View() string {
 	s += "\n(press ctrl+c to quit)\n"
 	return s
 }
+
+// viewPrompt renders the widget matching the current prompt's kind.
+func (m model) viewPrompt() string {
+	p := m.prompts[m.index]
+
+	var b strings.Builder
+	b.WriteString(m.helpStyle.Render(fmt.Sprintf("[%d/%d] %s", m.index+1, len(m.prompts), m.template)))
+	b.WriteString("\n" + p.title + "\n")
+	if p.help != "" {
+		b.WriteString(m.helpStyle.Render(p.help) + "\n")
+	}
+	b.WriteString("\n")
+
+	hint := "(press enter to confirm, esc to go back)"
+	switch p.kind {
+	case promptText:
+		b.WriteString(m.textInput.View() + "\n")
+	case promptToggle:
+		yes, no := "  Yes", "  No"
+		if m.cursor == 0 {
+			yes = "> Yes"
+		} else {
+			no = "> No"
+		}
+		b.WriteString(yes + "   " + no + "\n")
+		hint = "(←/→ to toggle, enter to confirm, esc to go back)"
+	case promptSelect:
+		for i, option := range p.options {
+			cursor := " "
+			if m.cursor == i {
+				cursor = ">"
+			}
+			b.WriteString(fmt.Sprintf("%s %s\n", cursor, option))
+		}
+	case promptMultiSelect:
+		for i, option := range p.options {
+			cursor := " "
+			if m.cursor == i {
+				cursor = ">"
+			}
+			check := "[ ]"
+			if m.selected[i] {
+				check = "[x]"
+			}
+			b.WriteString(fmt.Sprintf("%s %s %s\n", cursor, check, option))
+		}
+		hint = "(space to select, enter to confirm, esc to go back)"
+	}
+
+	b.WriteString("\n" + hint)
+	return b.String()
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update [project_dir]",
+	Short: "Applies changes from a newer template version to a project.",
+	Long: `Reads the template and answers recorded in the project's .forma-answers.yaml,
+renders both the recorded template revision and the installed one, and merges
+the changes between them into the project.
+
+Files that cannot be merged cleanly are left with conflict markers, or for binary
+files, the new template version is written next to them with a .rej suffix.
+Run 'forma upgrade' first to install the latest template version.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath := "."
+		if len(args) == 1 {
+			projectPath = args[0]
+		}
+
+		record, err := readProjectRecord(projectPath)
+		if err != nil {
+			fmt.Printf("Error reading project record: %v\n", err)
+			os.Exit(1)
+		}
+		if record.Revision == "" {
+			fmt.Printf("Error: template '%s' was not installed from git, so the version the project was generated from is unknown.\n", record.Template)
+			os.Exit(1)
+		}
+
+		templatesPath, err := getTemplatesPath()
+		if err != nil {
+			fmt.Printf("Error getting templates path: %v\n", err)
+			os.Exit(1)
+		}
+		templatePath := filepath.Join(templatesPath, record.Template)
+		headRevision := templateSource(templatePath).Revision
+		if headRevision == "" {
+			fmt.Printf("Error: template '%s' in '%s' was not installed from git.\n", record.Template, templatesPath)
+			os.Exit(1)
+		}
+		if headRevision == record.Revision {
+			fmt.Println("Project is already up to date with the installed template.")
+			return
+		}
+
+		fmt.Printf("Updating '%s' from template '%s' (%s → %s)\n", projectPath, record.Template, shortRevision(record.Revision), shortRevision(headRevision))
+
+		oldDir, _, err := renderRecorded(templatePath, record.Revision, record)
+		if err != nil {
+			fmt.Printf("Error rendering recorded template version: %v\n", err)
+			os.Exit(1)
+		}
+
+		newDir, newData, err := renderRecorded(templatePath, "", record)
+		if err != nil {
+			os.RemoveAll(oldDir)
+			fmt.Printf("Error rendering installed template version: %v\n", err)
+			os.Exit(1)
+		}
+
+		result, err := mergeTemplateChanges(oldDir, newDir, projectPath)
+		os.RemoveAll(oldDir)
+		os.RemoveAll(newDir)
+		if err != nil {
+			fmt.Printf("Error merging template changes: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Record the new revision, including defaults for any variables the template added.
+		updated := newProjectRecord(record.Template, templatePath, newData)
+		updated.Created = record.Created
+		if err := writeProjectRecord(projectPath, updated); err != nil {
+			fmt.Printf("Error updating project record: %v\n", err)
+			os.Exit(1)
+		}
+
+		result.print()
+		if len(result.conflicts) > 0 {
+			fmt.Println("⚠️  Resolve the conflicts above, then review and commit the changes.")
+			os.Exit(1)
+		}
+		fmt.Println("✅ Project updated successfully!")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+}
+
+// readProjectRecord reads the provenance record of a generated project.
+func readProjectRecord(projectPath string) (ProjectRecord, error) {
+	var record ProjectRecord
+
+	content, err := os.ReadFile(filepath.Join(projectPath, recordFileName))
+	if err != nil {
+		return record, fmt.Errorf("failed to read %s: %w", recordFileName, err)
+	}
+	if err := yaml.Unmarshal(content, &record); err != nil {
+		return record, fmt.Errorf("failed to parse %s: %w", recordFileName, err)
+	}
+	if record.Template == "" {
+		return record, fmt.Errorf("%s does not name a template", recordFileName)
+	}
+	return record, nil
+}
+
+// shortRevision abbreviates a commit hash for display.
+func shortRevision(revision string) string {
+	if len(revision) > 7 {
+		return revision[:7]
+	}
+	return revision
+}
+
+// checkoutRevision checks out a revision of a template into a temporary
+// directory. It returns that directory, to be removed by the caller, and the
+// path of the template inside it. Templates installed from a subdirectory are
+// cloned from their recorded URL; other templates are git checkouts themselves.
+func checkoutRevision(templatePath, revision string) (string, string, error) {
+	repo, subdir := templatePath, ""
+	if _, err := os.Stat(filepath.Join(templatePath, ".git")); err != nil {
+		origin, ok, err := readTemplateOrigin(templatePath)
+		if err != nil {
+			return "", "", err
+		}
+		if !ok || origin.URL == "" {
+			return "", "", fmt.Errorf("template in '%s' was not installed from git", templatePath)
+		}
+		repo, subdir = origin.URL, filepath.FromSlash(origin.Subdir)
+	}
+
+	dir, err := os.MkdirTemp("", "forma-template-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cloned, err := gitClone(repo, dir, false, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	hash, err := gitResolve(cloned, revision)
+	if err == nil {
+		err = gitCheckout(cloned, hash, "", true)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("failed to check out revision %s: %w", revision, err)
+	}
+	return dir, filepath.Join(dir, subdir), nil
+}
+
+// renderRecorded renders a template into a temporary directory using the
+// answers of a project record. An empty revision renders the installed
+// template as it is; otherwise that revision is checked out first.
+func renderRecorded(templatePath, revision string, record ProjectRecord) (string, TemplateData, error) {
+	var data TemplateData
+
+	sourcePath := templatePath
+	if revision != "" {
+		checkout, checkoutPath, err := checkoutRevision(templatePath, revision)
+		if err != nil {
+			return "", data, err
+		}
+		defer os.RemoveAll(checkout)
+		sourcePath = checkoutPath
+	}
+
+	config, err := loadTemplateConfig(sourcePath)
+	if err != nil {
+		return "", data, err
+	}
+	variables, err := resolveVariables(config.Variables, record.Answers)
+	if err != nil {
+		return "", data, err
+	}
+
+	// Reuse the recorded {{ .Timestamp }} so it does not show up as a change.
+	// Records written before it was kept only have the creation time.
+	timestamp := stringAnswer(record.Answers, "Timestamp")
+	if timestamp == "" {
+		created, err := time.Parse(time.RFC3339, record.Created)
+		if err != nil {
+			created = time.Now()
+		}
+		timestamp = created.Local().Format(time.RFC822)
+	}
+	data = TemplateData{
+		ProjectName: stringAnswer(record.Answers, "ProjectName"),
+		Author:      stringAnswer(record.Answers, "Author"),
+		Timestamp:   timestamp,
+		Variables:   variables,
+		delims:      config.Delimiters,
+	}
+
+	outputPath, err := os.MkdirTemp("", "forma-render-*")
+	if err != nil {
+		return "", data, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	if _, err := copyTemplate(sourcePath, outputPath, config, data); err != nil {
+		os.RemoveAll(outputPath)
+		return "", data, err
+	}
+	return outputPath, data, nil
+}
+
+// listFiles returns the slash-separated relative paths of all files and
+// symlinks below root.
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relativePath))
+		return nil
+	})
+	return files, err
+}
+
+// fileEntry is the content of a file, or the target of a symlink.
+type fileEntry struct {
+	exists  bool
+	symlink bool
+	content []byte
+	mode    fs.FileMode
+}
+
+func readEntry(path string) (fileEntry, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileEntry{}, nil
+	}
+	if err != nil {
+		return fileEntry{}, err
+	}
+
+	entry := fileEntry{exists: true, mode: info.Mode().Perm()}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return entry, err
+		}
+		entry.symlink = true
+		entry.content = []byte(target)
+		return entry, nil
+	}
+	entry.content, err = os.ReadFile(path)
+	return entry, err
+}
+
+func (e fileEntry) equal(other fileEntry) bool {
+	return e.exists == other.exists && e.symlink == other.symlink && bytes.Equal(e.content, other.content)
+}
+
+// writeEntry writes a file or recreates a symlink, creating parent directories.
+func writeEntry(path string, entry fileEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if entry.symlink {
+		return os.Symlink(string(entry.content), path)
+	}
+	if err := os.WriteFile(path, entry.content, entry.mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, entry.mode)
+}
+
+// mergeResult lists what a merge did to each affected file.
+type mergeResult struct {
+	updated   []string
+	added     []string
+	removed   []string
+	merged    []string
+	conflicts []string
+	skipped   []string
+}
+
+func (r mergeResult) print() {
+	groups := []struct {
+		label string
+		files []string
+	}{
+		{"Updated", r.updated},
+		{"Added", r.added},
+		{"Removed", r.removed},
+		{"Merged", r.merged},
+		{"Conflicts", r.conflicts},
+		{"Skipped", r.skipped},
+	}
+	for _, group := range groups {
+		for _, file := range group.files {
+			fmt.Printf("  %-10s %s\n", group.label+":", file)
+		}
+	}
+}
+
+// mergeTemplateChanges applies the differences between two renders of a
+// template (old and new) to a project, using a three-way merge for files
+// that were changed both in the template and in the project.
+func mergeTemplateChanges(oldDir, newDir, projectPath string) (mergeResult, error) {
+	var result mergeResult
+
+	oldFiles, err := listFiles(oldDir)
+	if err != nil {
+		return result, err
+	}
+	newFiles, err := listFiles(newDir)
+	if err != nil {
+		return result, err
+	}
+
+	seen := make(map[string]bool)
+	var all []string
+	for _, file := range append(oldFiles, newFiles...) {
+		if !seen[file] {
+			seen[file] = true
+			all = append(all, file)
+		}
+	}
+	sort.Strings(all)
+
+	for _, file := range all {
+		base, err := readEntry(filepath.Join(oldDir, file))
+		if err != nil {
+			return result, err
+		}
+		theirs, err := readEntry(filepath.Join(newDir, file))
+		if err != nil {
+			return result, err
+		}
+		target := filepath.Join(projectPath, file)
+		ours, err := readEntry(target)
+		if err != nil {
+			return result, err
+		}
+
+		switch {
+		case base.equal(theirs):
+			// The template did not change this file.
+		case ours.equal(theirs):
+			// The project already matches the new template.
+		case !theirs.exists:
+			// Removed from the template: only delete it if the project did not change it.
+			if ours.equal(base) {
+				if err := os.Remove(target); err != nil {
+					return result, err
+				}
+				result.removed = append(result.removed, file)
+			} else if ours.exists {
+				result.skipped = append(result.skipped, file+" (removed from template, modified locally)")
+			}
+		case !ours.exists && base.exists:
+			// The project deleted a file the template changed; respect the deletion.
+			result.skipped = append(result.skipped, file+" (deleted locally)")
+		case !ours.exists:
+			if err := writeEntry(target, theirs); err != nil {
+				return result, err
+			}
+			result.added = append(result.added, file)
+		case ours.equal(base):
+			if err := writeEntry(target, theirs); err != nil {
+				return result, err
+			}
+			result.updated = append(result.updated, file)
+		default:
+			clean, err := mergeFile(target, ours, base, theirs)
+			if err != nil {
+				return result, err
+			}
+			if clean {
+				result.merged = append(result.merged, file)
+			} else {
+				result.conflicts = append(result.conflicts, file)
+			}
+		}
+	}
+	return result, nil
+}
+
+// mergeFile three-way merges the template change (base → theirs) into the
+// project file. Text files get conflict markers where both sides changed the
+// same lines; binary files and symlinks keep the project version and get the
+// new template version written to a .rej file. It reports whether the merge
+// was clean.
+func mergeFile(target string, ours, base, theirs fileEntry) (bool, error) {
+	if ours.symlink || theirs.symlink || isBinary(ours.content) || isBinary(theirs.content) {
+		rejected := theirs
+		rejected.symlink = false
+		return false, writeEntry(target+".rej", rejected)
+	}
+
+	merged, clean := merge3(ours.content, base.content, theirs.content)
+	return clean, writeEntry(target, fileEntry{exists: true, content: merged, mode: ours.mode})
+}
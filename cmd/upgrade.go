@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeRef   string
+	upgradeCheck bool
+)
+
+// errPinned is returned for templates pinned to a tag or commit.
+var errPinned = errors.New("pinned")
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:     "upgrade [template_name...]",
+	Aliases: []string{"pull"},
+	Short:   "Fetches new commits for templates installed from git.",
+	Long: `Fetches new commits for the given templates, or for every template installed
+from git, and shows the subjects of the commits between the installed and the
+new revision.
+
+Templates installed from the default branch or from a branch follow that branch.
+Templates pinned to a tag or commit with 'forma add --ref' are left alone; pass
+--ref to move a single template to another tag, branch or commit.
+
+Projects generated from a template can then be brought up to date with 'forma update'.`,
+	Example: `  forma upgrade
+  forma upgrade go-service --check
+  forma upgrade go-service --ref v1.5.0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templatesPath, err := getTemplatesPath()
+		if err != nil {
+			fmt.Printf("Error getting templates path: %v\n", err)
+			os.Exit(1)
+		}
+
+		names := args
+		if len(names) == 0 {
+			if upgradeRef != "" {
+				fmt.Println("Error: --ref can only be used with a single template.")
+				os.Exit(1)
+			}
+			if names, err = getAvailableTemplates(); err != nil {
+				fmt.Printf("Error getting templates: %v\n", err)
+				os.Exit(1)
+			}
+		} else if upgradeRef != "" && len(names) > 1 {
+			fmt.Println("Error: --ref can only be used with a single template.")
+			os.Exit(1)
+		}
+
+		failed := 0
+		for _, name := range names {
+			templatePath := filepath.Join(templatesPath, name)
+			if _, err := os.Stat(templatePath); err != nil {
+				fmt.Printf("Error: template '%s' does not exist in '%s'.\n", name, templatesPath)
+				failed++
+				continue
+			}
+			if err := upgradeTemplate(name, templatePath, upgradeRef, upgradeCheck); err != nil {
+				fmt.Printf("Error upgrading template '%s': %v\n", name, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.Flags().StringVar(&upgradeRef, "ref", "", "Move the template to this tag, branch or commit")
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only show available changes, without installing them")
+}
+
+// upgradeTemplate fetches new commits for one template and moves it to the
+// newest revision of the ref it follows, or to newRef when given.
+func upgradeTemplate(name, templatePath, newRef string, check bool) error {
+	origin := templateSource(templatePath)
+	_, statErr := os.Stat(filepath.Join(templatePath, ".git"))
+	isClone := statErr == nil
+	if origin.URL == "" || (!isClone && origin.Revision == "") {
+		if newRef != "" {
+			return fmt.Errorf("it was not installed from git")
+		}
+		fmt.Printf("%s: skipped, not installed from git.\n", name)
+		return nil
+	}
+
+	// Full clones are fetched in place; templates installed from a
+	// subdirectory are cloned again to a temporary directory.
+	repoPath := templatePath
+	var repo *git.Repository
+	if isClone {
+		var err error
+		if repo, err = gitOpen(repoPath); err != nil {
+			return err
+		}
+		if err := gitFetch(repo); err != nil {
+			return err
+		}
+	} else {
+		tempPath, err := os.MkdirTemp("", "forma-clone-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tempPath)
+		if repo, err = gitClone(origin.URL, tempPath, false, nil); err != nil {
+			return err
+		}
+		repoPath = tempPath
+	}
+
+	ref := origin.Ref
+	if newRef != "" {
+		ref = newRef
+	}
+	target, branch, err := upgradeTarget(repo, ref, newRef != "")
+	if errors.Is(err, errPinned) {
+		fmt.Printf("%s: pinned to %s, skipped. Use --ref to move it.\n", name, ref)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if target == origin.Revision && ref == origin.Ref {
+		fmt.Printf("%s: up to date at %s.\n", name, shortRevision(target))
+		return nil
+	}
+
+	fmt.Printf("%s: %s → %s\n", name, shortRevision(origin.Revision), shortRevision(target))
+	printChangelog(repo, origin.Revision, target, origin.Subdir)
+	if check {
+		return nil
+	}
+
+	if isClone {
+		// Keep a branch checked out when following one, so the clone stays usable with plain git.
+		if err := gitCheckout(repo, plumbing.NewHash(target), branch, false); err != nil {
+			return err
+		}
+	} else {
+		if err := gitCheckout(repo, plumbing.NewHash(target), "", true); err != nil {
+			return err
+		}
+		if err := replaceTemplate(templatePath, filepath.Join(repoPath, filepath.FromSlash(origin.Subdir))); err != nil {
+			return err
+		}
+		if err := ensureTemplateYAML(templatePath, name); err != nil {
+			fmt.Printf("Warning: could not create placeholder template.yaml: %v\n", err)
+		}
+	}
+
+	origin.Ref = ref
+	origin.Revision = target
+	if err := writeTemplateOrigin(templatePath, origin); err != nil {
+		return err
+	}
+	if err := retrustTemplate(name, templatePath, origin.URL); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	fmt.Printf("%s: upgraded to %s.\n", name, shortRevision(target))
+	return nil
+}
+
+// upgradeTarget resolves the commit a template following ref should move to.
+// An empty ref follows the repository's default branch. For branches, the
+// local branch name is returned too. Tags and commits are pinned unless the
+// ref was given explicitly.
+func upgradeTarget(repo *git.Repository, ref string, explicit bool) (target, branch string, err error) {
+	if ref == "" {
+		if ref, err = gitDefaultBranch(repo); err != nil {
+			return "", "", err
+		}
+		explicit = true
+	}
+	hash, err := gitResolve(repo, ref)
+	if err != nil {
+		return "", "", err
+	}
+	if gitRemoteBranch(repo, ref) {
+		return hash.String(), ref, nil
+	}
+	if !explicit {
+		return "", "", errPinned
+	}
+	return hash.String(), "", nil
+}
+
+// printChangelog prints the subjects of the commits between two revisions,
+// limited to the template's subdirectory if it has one.
+func printChangelog(repo *git.Repository, from, to, subdir string) {
+	if from != "" && gitIsAncestor(repo, to, from) {
+		fmt.Println("  (moving back to an older revision)")
+		return
+	}
+
+	lines, err := gitLog(repo, from, to, subdir)
+	if err != nil {
+		// The installed revision may no longer exist upstream, e.g. after a force push.
+		fmt.Println("  (changelog unavailable)")
+		return
+	}
+	if len(lines) == 0 {
+		fmt.Println("  (no commits changing the template)")
+		return
+	}
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// replaceTemplate replaces an installed template with the contents of
+// sourcePath, keeping the old one until the copy succeeded.
+func replaceTemplate(templatePath, sourcePath string) error {
+	if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory '%s' no longer exists in the repository", filepath.Base(sourcePath))
+	}
+
+	newPath := siblingPath(templatePath, "forma-upgrade")
+	if err := copyDir(sourcePath, newPath); err != nil {
+		os.RemoveAll(newPath)
+		return fmt.Errorf("failed to copy template: %w", err)
+	}
+	oldPath := siblingPath(templatePath, "forma-backup")
+	if err := os.Rename(templatePath, oldPath); err != nil {
+		os.RemoveAll(newPath)
+		return fmt.Errorf("failed to move old template aside: %w", err)
+	}
+	if err := os.Rename(newPath, templatePath); err != nil {
+		os.Rename(oldPath, templatePath)
+		os.RemoveAll(newPath)
+		return fmt.Errorf("failed to move new template into place: %w", err)
+	}
+	return os.RemoveAll(oldPath)
+}
+
+// retrustTemplate re-approves the hooks of an upgraded template from a trusted
+// source, and otherwise warns if they changed and are now disabled.
+func retrustTemplate(name, templatePath, source string) error {
+	config, err := loadTemplateConfig(templatePath)
+	if err != nil {
+		return err
+	}
+	store, err := loadTrustStore()
+	if err != nil {
+		return err
+	}
+	if store.trustedSource(source) && hasHooks(config.Hooks) {
+		if err := store.approve(name, config.Hooks); err != nil {
+			return err
+		}
+		return store.save()
+	}
+
+	trust, err := hooksTrust(name, config.Hooks)
+	if err != nil {
+		return err
+	}
+	if trust == hooksChanged {
+		fmt.Printf("⚠️  Hooks of template '%s' changed and are disabled until you review them with 'forma trust %s'.\n", name, name)
+	}
+	return nil
+}
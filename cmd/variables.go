@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported variable types for the `variables:` section of template.yaml.
+const (
+	varTypeString      = "string"
+	varTypeBool        = "bool"
+	varTypeInt         = "int"
+	varTypeChoice      = "choice"
+	varTypeMultiChoice = "multi-choice"
+)
+
+// builtinVariables are the names always provided by TemplateData. Template
+// variables may not redefine them.
+var builtinVariables = map[string]bool{
+	"ProjectName": true,
+	"Author":      true,
+	"Timestamp":   true,
+}
+
+var variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Variable describes a single value a template asks for when a project is created.
+type Variable struct {
+	Name     string      `yaml:"name"`
+	Type     string      `yaml:"type"`
+	Default  interface{} `yaml:"default"`
+	Help     string      `yaml:"help"`
+	Validate string      `yaml:"validate"`
+	Choices  []string    `yaml:"choices"`
+}
+
+// kind returns the variable type, defaulting to string when none is given.
+func (v Variable) kind() string {
+	if v.Type == "" {
+		return varTypeString
+	}
+	return v.Type
+}
+
+// check verifies that the variable definition itself is well formed.
+func (v Variable) check() error {
+	if !variableNameRe.MatchString(v.Name) {
+		return fmt.Errorf("invalid variable name %q", v.Name)
+	}
+	if builtinVariables[v.Name] {
+		return fmt.Errorf("variable %q shadows a built-in value", v.Name)
+	}
+
+	switch v.kind() {
+	case varTypeString, varTypeBool, varTypeInt:
+	case varTypeChoice, varTypeMultiChoice:
+		if len(v.Choices) == 0 {
+			return fmt.Errorf("variable %q of type %s needs at least one choice", v.Name, v.kind())
+		}
+	default:
+		return fmt.Errorf("variable %q has unknown type %q", v.Name, v.Type)
+	}
+
+	if v.Validate != "" {
+		if _, err := regexp.Compile(v.Validate); err != nil {
+			return fmt.Errorf("variable %q has an invalid validation regex: %w", v.Name, err)
+		}
+	}
+
+	if v.Default != nil {
+		if _, err := v.coerce(v.Default); err != nil {
+			return fmt.Errorf("variable %q has an invalid default: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// defaultValue returns the typed default for the variable, or the zero value
+// of its type when no default is declared.
+func (v Variable) defaultValue() interface{} {
+	if v.Default != nil {
+		if value, err := v.coerce(v.Default); err == nil {
+			return value
+		}
+	}
+	switch v.kind() {
+	case varTypeBool:
+		return false
+	case varTypeInt:
+		return 0
+	case varTypeChoice:
+		return v.Choices[0]
+	case varTypeMultiChoice:
+		return []string{}
+	default:
+		return ""
+	}
+}
+
+// parse converts user input into a typed value and validates it.
+// Multi-choice answers are given as a comma-separated list.
+func (v Variable) parse(raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if v.kind() == varTypeMultiChoice {
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return v.coerce(items)
+	}
+	return v.coerce(raw)
+}
+
+// coerce converts a value decoded from YAML or typed by the user into the
+// Go type matching the variable type, then validates it.
+func (v Variable) coerce(value interface{}) (interface{}, error) {
+	var result interface{}
+
+	switch v.kind() {
+	case varTypeString, varTypeChoice:
+		result = fmt.Sprint(value)
+	case varTypeBool:
+		switch b := value.(type) {
+		case bool:
+			result = b
+		default:
+			switch strings.ToLower(fmt.Sprint(value)) {
+			case "y", "yes", "true", "1", "on":
+				result = true
+			case "n", "no", "false", "0", "off":
+				result = false
+			default:
+				return nil, fmt.Errorf("%q is not a yes/no value", fmt.Sprint(value))
+			}
+		}
+	case varTypeInt:
+		switch n := value.(type) {
+		case int:
+			result = n
+		default:
+			parsed, err := strconv.Atoi(fmt.Sprint(value))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a whole number", fmt.Sprint(value))
+			}
+			result = parsed
+		}
+	case varTypeMultiChoice:
+		items := []string{}
+		switch list := value.(type) {
+		case []string:
+			items = append(items, list...)
+		case []interface{}:
+			for _, item := range list {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			if s := fmt.Sprint(value); s != "" {
+				items = append(items, s)
+			}
+		}
+		result = items
+	default:
+		return nil, fmt.Errorf("unknown type %q", v.Type)
+	}
+
+	if err := v.validate(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validate checks a typed value against the declared choices and regex.
+func (v Variable) validate(value interface{}) error {
+	switch v.kind() {
+	case varTypeChoice:
+		if !containsString(v.Choices, value.(string)) {
+			return fmt.Errorf("%q is not one of: %s", value, strings.Join(v.Choices, ", "))
+		}
+	case varTypeMultiChoice:
+		for _, item := range value.([]string) {
+			if !containsString(v.Choices, item) {
+				return fmt.Errorf("%q is not one of: %s", item, strings.Join(v.Choices, ", "))
+			}
+		}
+	}
+
+	if v.Validate != "" && (v.kind() == varTypeString || v.kind() == varTypeInt) {
+		re := regexp.MustCompile(v.Validate)
+		if s := fmt.Sprint(value); !re.MatchString(s) {
+			return fmt.Errorf("%q does not match %s", s, v.Validate)
+		}
+	}
+	return nil
+}
+
+// checkVariables validates every variable definition in a template config.
+func checkVariables(vars []Variable) error {
+	seen := make(map[string]bool)
+	for _, v := range vars {
+		if err := v.check(); err != nil {
+			return err
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("variable %q is declared more than once", v.Name)
+		}
+		seen[v.Name] = true
+	}
+	return nil
+}
+
+// resolveVariables builds the final variable values for a template, using the
+// provided answers where present and each variable's default otherwise.
+func resolveVariables(vars []Variable, answers map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(vars))
+	for _, v := range vars {
+		answer, ok := answers[v.Name]
+		if !ok {
+			values[v.Name] = v.defaultValue()
+			continue
+		}
+		var value interface{}
+		var err error
+		if raw, isString := answer.(string); isString {
+			// Strings come from --set or the answers file and are parsed like user input.
+			value, err = v.parse(raw)
+		} else {
+			value, err = v.coerce(answer)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", v.Name, err)
+		}
+		values[v.Name] = value
+	}
+	return values, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}